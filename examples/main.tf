@@ -14,7 +14,7 @@ resource "gitlab-repository-files_gitlab_repository_file" "this" {
   project        = gitlab_project.foo.id
   file_path      = "meow.txt"
   branch         = "main"
-  content        = base64encode("hello world")
+  content        = "hello world"
   author_email   = "meow@catnip.com"
   author_name    = "Meow Meowington"
   commit_message = "feature: add launch codes"
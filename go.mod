@@ -6,4 +6,5 @@ require (
 	github.com/hashicorp/terraform-plugin-docs v0.5.1
 	github.com/hashicorp/terraform-plugin-sdk/v2 v2.8.0
 	github.com/xanzy/go-gitlab v0.51.1
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,220 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const (
+	commitActionCreate  = "create"
+	commitActionUpdate  = "update"
+	commitActionDelete  = "delete"
+	commitActionMove    = "move"
+	commitActionRestore = "restore"
+)
+
+var (
+	commitTrailerKeyPattern  = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*$`)
+	commitTrailerLinePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*: .+$`)
+	coAuthorPattern          = regexp.MustCompile(`^[^<>\n]+ <[^<>\s]+@[^<>\s]+>$`)
+)
+
+// defaultCommitMessageTemplates are used for the actions without a template
+// in the resource or provider configuration.
+var defaultCommitMessageTemplates = map[string]string{
+	commitActionCreate:  "{commit_message}",
+	commitActionUpdate:  "{commit_message}",
+	commitActionDelete:  "[DELETE]: {commit_message}",
+	commitActionMove:    "{commit_message}",
+	commitActionRestore: "[RESTORE]: {commit_message}",
+}
+
+// commitMessageTemplatesSchema returns the schema of the `commit_message_templates` block,
+// which is shared by the provider and the resources.
+func commitMessageTemplatesSchema(description string) *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Optional:    true,
+		MaxItems:    1,
+		Description: description + " The placeholders `{commit_message}`, `{action}`, `{file_path}`, `{branch}` and `{project}` are replaced in the templates. There is no placeholder for the address of the Terraform resource, because Terraform doesn't pass it to providers, it can be written into the `commit_message` instead.",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				commitActionCreate: {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Description: "The commit message template for creating files. Defaults to `{commit_message}`.",
+				},
+				commitActionUpdate: {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Description: "The commit message template for updating files. Defaults to `{commit_message}`.",
+				},
+				commitActionDelete: {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Description: "The commit message template for deleting files. Defaults to `[DELETE]: {commit_message}`.",
+				},
+				commitActionMove: {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Description: "The commit message template for moving files. Defaults to `{commit_message}`.",
+				},
+				commitActionRestore: {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Description: "The commit message template for restoring files on destroy. Defaults to `[RESTORE]: {commit_message}`.",
+				},
+			},
+		},
+	}
+}
+
+// commitTrailersSchema returns the schema of the `trailers` attribute, which is shared by the resources.
+func commitTrailersSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:         schema.TypeMap,
+		Optional:     true,
+		Elem:         &schema.Schema{Type: schema.TypeString},
+		ValidateFunc: validateCommitTrailers,
+		Description:  "Git trailers to add to every commit message, e.g. `{ \"Signed-off-by\" = \"Meow Meowington <meow@catnip.com>\" }`.",
+	}
+}
+
+// commitCoAuthorsSchema returns the schema of the `co_authors` attribute, which is shared by the resources.
+func commitCoAuthorsSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Optional:    true,
+		Elem:        &schema.Schema{Type: schema.TypeString, ValidateFunc: validateCoAuthor},
+		Description: "Co-authors to add as `Co-authored-by` trailers to every commit message. They must be given as `Name <email>`.",
+	}
+}
+
+func validateCommitTrailers(v interface{}, k string) (we []string, errors []error) {
+	for key, value := range v.(map[string]interface{}) {
+		if !commitTrailerKeyPattern.MatchString(key) {
+			errors = append(errors, fmt.Errorf("%s: trailer key %q must only consist of alphanumeric characters and dashes", k, key))
+		}
+		if value := value.(string); strings.TrimSpace(value) == "" || strings.Contains(value, "\n") {
+			errors = append(errors, fmt.Errorf("%s: value of trailer %q must be a non-empty single line", k, key))
+		}
+	}
+	return
+}
+
+func validateCoAuthor(v interface{}, k string) (we []string, errors []error) {
+	if !coAuthorPattern.MatchString(v.(string)) {
+		errors = append(errors, fmt.Errorf("%s: co-author %q must be given as `Name <email>`", k, v.(string)))
+	}
+	return
+}
+
+// expandCommitMessageTemplates returns the configured, non-empty templates by action.
+func expandCommitMessageTemplates(v interface{}) map[string]string {
+	templates := map[string]string{}
+	blocks, ok := v.([]interface{})
+	if !ok || len(blocks) == 0 || blocks[0] == nil {
+		return templates
+	}
+
+	for action, template := range blocks[0].(map[string]interface{}) {
+		if template := template.(string); template != "" {
+			templates[action] = template
+		}
+	}
+	return templates
+}
+
+// commitMessage returns the commit message for the given action on the given files.
+// The template of the resource takes precedence over the one of the provider,
+// which in turn takes precedence over the default template.
+func commitMessage(meta *Meta, d *schema.ResourceData, action string, filePaths ...string) string {
+	template, ok := expandCommitMessageTemplates(d.Get("commit_message_templates"))[action]
+	if !ok && meta != nil && meta.Config != nil {
+		template, ok = meta.Config.CommitMessageTemplates[action]
+	}
+	if !ok {
+		template = defaultCommitMessageTemplates[action]
+	}
+
+	// the address of the resource can't be offered as a placeholder, because the SDK doesn't expose it to the provider.
+	message := renderCommitMessageTemplate(template, map[string]string{
+		"commit_message": d.Get("commit_message").(string),
+		"action":         action,
+		"file_path":      strings.Join(filePaths, ", "),
+		"branch":         d.Get("branch").(string),
+		"project":        d.Get("project").(string),
+	})
+
+	if skipCI, _ := repositoryCommitSkipCI(meta, d); skipCI {
+		message = appendSkipCIMarker(message)
+	}
+
+	return appendCommitTrailers(message, repositoryCommitTrailers(d))
+}
+
+// repositoryCommitTrailers returns the configured trailers as `Key: value` lines.
+// The trailers are sorted by key and followed by the co-authors in their configured order.
+func repositoryCommitTrailers(d *schema.ResourceData) []string {
+	trailers := []string{}
+	for key, value := range d.Get("trailers").(map[string]interface{}) {
+		trailers = append(trailers, fmt.Sprintf("%s: %s", key, strings.TrimSpace(value.(string))))
+	}
+	sort.Strings(trailers)
+
+	for _, coAuthor := range d.Get("co_authors").([]interface{}) {
+		trailers = append(trailers, fmt.Sprintf("Co-authored-by: %s", coAuthor.(string)))
+	}
+	return trailers
+}
+
+// appendCommitTrailers appends the given trailers to the commit message, like `git interpret-trailers` does.
+// Trailers which are already part of the message, or given multiple times, are only added once.
+// If the last paragraph of the message already consists of trailers, they are appended to it.
+func appendCommitTrailers(message string, trailers []string) string {
+	message = strings.TrimRight(message, "\n")
+	paragraphs := strings.Split(message, "\n\n")
+	lastParagraph := strings.Split(paragraphs[len(paragraphs)-1], "\n")
+
+	existingTrailers := map[string]bool{}
+	isTrailerParagraph := len(paragraphs) > 1
+	for _, line := range lastParagraph {
+		if !commitTrailerLinePattern.MatchString(line) {
+			isTrailerParagraph = false
+			continue
+		}
+		existingTrailers[strings.ToLower(line)] = true
+	}
+
+	newTrailers := []string{}
+	for _, trailer := range trailers {
+		if existingTrailers[strings.ToLower(trailer)] {
+			continue
+		}
+		existingTrailers[strings.ToLower(trailer)] = true
+		newTrailers = append(newTrailers, trailer)
+	}
+	if len(newTrailers) == 0 {
+		return message
+	}
+
+	separator := "\n\n"
+	if isTrailerParagraph {
+		separator = "\n"
+	}
+	return message + separator + strings.Join(newTrailers, "\n")
+}
+
+// renderCommitMessageTemplate replaces the `{name}` placeholders in the template with the given values.
+// Unknown placeholders are kept as they are.
+func renderCommitMessageTemplate(template string, values map[string]string) string {
+	replacements := make([]string, 0, 2*len(values))
+	for name, value := range values {
+		replacements = append(replacements, "{"+name+"}", value)
+	}
+	return strings.NewReplacer(replacements...).Replace(template)
+}
@@ -0,0 +1,145 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestRenderCommitMessageTemplate(t *testing.T) {
+	message := renderCommitMessageTemplate("chore({branch}): {action} {file_path} {unknown}", map[string]string{
+		"branch":    "main",
+		"action":    "delete",
+		"file_path": "meow.txt",
+	})
+	if message != "chore(main): delete meow.txt {unknown}" {
+		t.Fatalf("got commit message %q", message)
+	}
+}
+
+func TestCommitMessage(t *testing.T) {
+	meta := &Meta{Config: &Config{CommitMessageTemplates: map[string]string{
+		commitActionDelete: "chore: remove {file_path}",
+		commitActionUpdate: "chore: update {file_path}",
+	}}}
+	d := schema.TestResourceDataRaw(t, resourceGitlabRepositoryFile().Schema, map[string]interface{}{
+		"project":        "42",
+		"branch":         "main",
+		"file_path":      "meow.txt",
+		"commit_message": "feature: add launch codes",
+		"commit_message_templates": []interface{}{
+			map[string]interface{}{
+				commitActionUpdate: "fix: {commit_message}",
+			},
+		},
+	})
+
+	cases := []struct {
+		givenAction     string
+		expectedMessage string
+	}{
+		{
+			givenAction:     commitActionCreate,
+			expectedMessage: "feature: add launch codes",
+		},
+		{
+			givenAction:     commitActionUpdate,
+			expectedMessage: "fix: feature: add launch codes",
+		},
+		{
+			givenAction:     commitActionDelete,
+			expectedMessage: "chore: remove meow.txt",
+		},
+	}
+
+	for _, c := range cases {
+		if message := commitMessage(meta, d, c.givenAction, "meow.txt"); message != c.expectedMessage {
+			t.Fatalf("got commit message %q for %s; want %q", message, c.givenAction, c.expectedMessage)
+		}
+	}
+
+	if message := commitMessage(&Meta{Config: &Config{}}, d, commitActionDelete, "meow.txt"); message != "[DELETE]: feature: add launch codes" {
+		t.Fatalf("got commit message %q; want the default delete commit message", message)
+	}
+}
+
+func TestAppendCommitTrailers(t *testing.T) {
+	cases := []struct {
+		givenMessage    string
+		givenTrailers   []string
+		expectedMessage string
+	}{
+		{
+			givenMessage:    "feature: add launch codes",
+			givenTrailers:   nil,
+			expectedMessage: "feature: add launch codes",
+		},
+		{
+			givenMessage:    "feature: add launch codes\n",
+			givenTrailers:   []string{"Signed-off-by: Meow <meow@catnip.com>", "Co-authored-by: Purr <purr@catnip.com>"},
+			expectedMessage: "feature: add launch codes\n\nSigned-off-by: Meow <meow@catnip.com>\nCo-authored-by: Purr <purr@catnip.com>",
+		},
+		{
+			givenMessage:    "feature: add launch codes\n\nSigned-off-by: Meow <meow@catnip.com>",
+			givenTrailers:   []string{"signed-off-by: Meow <meow@catnip.com>", "Co-authored-by: Purr <purr@catnip.com>", "Co-authored-by: Purr <purr@catnip.com>"},
+			expectedMessage: "feature: add launch codes\n\nSigned-off-by: Meow <meow@catnip.com>\nCo-authored-by: Purr <purr@catnip.com>",
+		},
+		{
+			givenMessage:    "feature: add launch codes\n\nSee: the docs, please",
+			givenTrailers:   []string{"Ticket: CAT-42"},
+			expectedMessage: "feature: add launch codes\n\nSee: the docs, please\nTicket: CAT-42",
+		},
+		{
+			givenMessage:    "feature: add launch codes\n\nThe codes are\nsecret.",
+			givenTrailers:   []string{"Ticket: CAT-42"},
+			expectedMessage: "feature: add launch codes\n\nThe codes are\nsecret.\n\nTicket: CAT-42",
+		},
+	}
+
+	for _, c := range cases {
+		if message := appendCommitTrailers(c.givenMessage, c.givenTrailers); message != c.expectedMessage {
+			t.Fatalf("got commit message %q for %q; want %q", message, c.givenMessage, c.expectedMessage)
+		}
+	}
+}
+
+func TestCommitMessage_trailers(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceGitlabRepositoryFile().Schema, map[string]interface{}{
+		"project":        "42",
+		"branch":         "main",
+		"file_path":      "meow.txt",
+		"commit_message": "feature: add launch codes",
+		"trailers": map[string]interface{}{
+			"Ticket":        "CAT-42",
+			"Signed-off-by": "Meow <meow@catnip.com>",
+		},
+		"co_authors": []interface{}{"Purr <purr@catnip.com>", "Purr <purr@catnip.com>"},
+	})
+
+	expectedMessage := "feature: add launch codes\n\nSigned-off-by: Meow <meow@catnip.com>\nTicket: CAT-42\nCo-authored-by: Purr <purr@catnip.com>"
+	if message := commitMessage(nil, d, commitActionUpdate, "meow.txt"); message != expectedMessage {
+		t.Fatalf("got commit message %q; want %q", message, expectedMessage)
+	}
+}
+
+func TestValidateCommitTrailers(t *testing.T) {
+	if _, errs := validateCommitTrailers(map[string]interface{}{"Signed-off-by": "Meow <meow@catnip.com>"}, "trailers"); len(errs) != 0 {
+		t.Fatalf("got errors for valid trailers: %v", errs)
+	}
+	for _, trailers := range []map[string]interface{}{
+		{"Signed off by": "Meow"},
+		{"-Ticket": "CAT-42"},
+		{"Ticket": " "},
+		{"Ticket": "CAT-42\nCAT-43"},
+	} {
+		if _, errs := validateCommitTrailers(trailers, "trailers"); len(errs) == 0 {
+			t.Fatalf("got no errors for invalid trailers %v", trailers)
+		}
+	}
+
+	for _, coAuthor := range []string{"Meow", "meow@catnip.com", "<meow@catnip.com>", "Meow <meow>"} {
+		if _, errs := validateCoAuthor(coAuthor, "co_authors"); len(errs) == 0 {
+			t.Fatalf("got no errors for invalid co-author %q", coAuthor)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/x509"
 	"io/ioutil"
 	"net/http"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/logging"
 	"github.com/xanzy/go-gitlab"
@@ -18,10 +19,36 @@ type Config struct {
 	CACertFile string
 	ClientCert string
 	ClientKey  string
+
+	// Retries is the number of times a write to a repository is retried
+	// after a conflicting change of the branch or a transient API error.
+	Retries int
+	// MaxRetryWait is the maximum time to wait before a retry.
+	MaxRetryWait time.Duration
+
+	// CommitMessageTemplates are the default commit message templates by action.
+	CommitMessageTemplates map[string]string
+
+	// SkipCI prevents pipelines for commits of all resources.
+	SkipCI bool
+	// SkipCIExceptLast prevents pipelines for commits of all resources,
+	// but starts a single pipeline for the last commit to each branch.
+	SkipCIExceptLast bool
 }
 
-// Client returns a *gitlab.Client to interact with the configured gitlab instance
-func (c *Config) Client() (*gitlab.Client, error) {
+// Meta is passed to all resources and holds the client to interact with gitlab
+// together with the provider configuration it was created from.
+type Meta struct {
+	Client *gitlab.Client
+	Config *Config
+	// HTTPClient is used for requests which aren't part of the GitLab API, e.g. to the Git LFS API.
+	HTTPClient *http.Client
+	// Pipelines are the pipelines deferred for branches with skipped CI.
+	Pipelines *deferredPipelines
+}
+
+// HTTPClient returns a *http.Client which uses the configured TLS settings
+func (c *Config) HTTPClient() (*http.Client, error) {
 	// Configure TLS/SSL
 	tlsConfig := &tls.Config{}
 
@@ -55,12 +82,24 @@ func (c *Config) Client() (*gitlab.Client, error) {
 	t.TLSClientConfig = tlsConfig
 	t.MaxIdleConnsPerHost = 100
 
+	return &http.Client{
+		Transport: logging.NewTransport("GitLab", t),
+	}, nil
+}
+
+// Client returns a *gitlab.Client to interact with the configured gitlab instance
+func (c *Config) Client() (*gitlab.Client, error) {
+	httpClient, err := c.HTTPClient()
+	if err != nil {
+		return nil, err
+	}
+
 	opts := []gitlab.ClientOptionFunc{
-		gitlab.WithHTTPClient(
-			&http.Client{
-				Transport: logging.NewTransport("GitLab", t),
-			},
-		),
+		gitlab.WithHTTPClient(httpClient),
+		// Writes are retried by retryRepositoryWrite within the configured limits.
+		// The client must not retry on its own, because it would resend commits after server errors
+		// and multiply the configured retries.
+		gitlab.WithoutRetries(),
 	}
 
 	if c.BaseURL != "" {
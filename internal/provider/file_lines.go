@@ -0,0 +1,76 @@
+package provider
+
+import (
+	"sort"
+	"strings"
+)
+
+// fileLineSet returns the set of lines of the file content.
+// Lines are compared without a trailing carriage return, so that files with CRLF line endings are supported.
+func fileLineSet(content string) map[string]bool {
+	lines := map[string]bool{}
+	for _, line := range strings.Split(content, "\n") {
+		lines[strings.TrimSuffix(line, "\r")] = true
+	}
+	return lines
+}
+
+// missingFileLines returns the sorted lines which aren't contained in the file content.
+func missingFileLines(content string, lines []string) []string {
+	present := fileLineSet(content)
+	var missing []string
+	for _, line := range lines {
+		if !present[line] {
+			missing = append(missing, line)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// presentFileLines returns the sorted lines which are contained in the file content.
+func presentFileLines(content string, lines []string) []string {
+	present := fileLineSet(content)
+	var found []string
+	for _, line := range lines {
+		if present[line] {
+			found = append(found, line)
+		}
+	}
+	sort.Strings(found)
+	return found
+}
+
+// appendFileLines returns the file content with the lines appended.
+func appendFileLines(content string, lines []string) string {
+	if len(lines) == 0 {
+		return content
+	}
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return content + strings.Join(lines, "\n") + "\n"
+}
+
+// removeFileLines returns the file content without all occurrences of the lines.
+func removeFileLines(content string, lines []string) string {
+	remove := map[string]bool{}
+	for _, line := range lines {
+		remove[line] = true
+	}
+
+	var buf strings.Builder
+	for offset := 0; offset < len(content); {
+		next := len(content)
+		if i := strings.IndexByte(content[offset:], '\n'); i >= 0 {
+			next = offset + i + 1
+		}
+
+		line := strings.TrimSuffix(strings.TrimSuffix(content[offset:next], "\n"), "\r")
+		if !remove[line] {
+			buf.WriteString(content[offset:next])
+		}
+		offset = next
+	}
+	return buf.String()
+}
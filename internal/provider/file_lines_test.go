@@ -0,0 +1,78 @@
+package provider
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestFileLines(t *testing.T) {
+	cases := []struct {
+		givenContent    string
+		lines           []string
+		expectedMissing []string
+		expectedContent string
+		expectedRemoved string
+	}{
+		{
+			givenContent:    "",
+			lines:           []string{"*.tfstate", ".terraform/"},
+			expectedMissing: []string{"*.tfstate", ".terraform/"},
+			expectedContent: "*.tfstate\n.terraform/\n",
+			expectedRemoved: "",
+		},
+		{
+			givenContent:    "*.log\n.terraform/\n/build",
+			lines:           []string{".terraform/", "*.tfstate"},
+			expectedMissing: []string{"*.tfstate"},
+			expectedContent: "*.log\n.terraform/\n/build\n*.tfstate\n",
+			expectedRemoved: "*.log\n.terraform/\n/build\n",
+		},
+		{
+			givenContent:    "*.log\r\n*.tfstate\r\n",
+			lines:           []string{"*.tfstate"},
+			expectedMissing: nil,
+			expectedContent: "*.log\r\n*.tfstate\r\n",
+			expectedRemoved: "*.log\r\n*.tfstate\r\n",
+		},
+	}
+
+	for _, c := range cases {
+		missing := missingFileLines(c.givenContent, c.lines)
+		if !reflect.DeepEqual(missing, c.expectedMissing) {
+			t.Fatalf("got missing lines %q in %q; want %q", missing, c.givenContent, c.expectedMissing)
+		}
+		content := appendFileLines(c.givenContent, missing)
+		if content != c.expectedContent {
+			t.Fatalf("got %q after appending %q to %q; want %q", content, missing, c.givenContent, c.expectedContent)
+		}
+		if present := presentFileLines(content, c.lines); len(present) != len(c.lines) {
+			t.Fatalf("got present lines %q in %q; want %q", present, content, c.lines)
+		}
+		if removed := removeFileLines(content, missing); removed != c.expectedRemoved {
+			t.Fatalf("got %q after removing %q from %q; want %q", removed, missing, content, c.expectedRemoved)
+		}
+	}
+
+	if removed := removeFileLines("a\r\nb\r\na\r\nc", []string{"a", "c"}); removed != "b\r\n" {
+		t.Fatalf("got %q after removing all occurrences; want %q", removed, "b\r\n")
+	}
+}
+
+func TestEnsureRepositoryFileLines(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceGitlabRepositoryFileLines().Schema, map[string]interface{}{
+		"lines": []interface{}{"*.tfstate", ".terraform/"},
+	})
+
+	content, addedLines := ensureRepositoryFileLines(d, "*.log\n")
+	if content != "*.log\n*.tfstate\n.terraform/\n" || !reflect.DeepEqual(addedLines, []string{"*.tfstate", ".terraform/"}) {
+		t.Fatalf("got %q with added lines %q", content, addedLines)
+	}
+
+	// a retry after someone else added one of the lines doesn't claim it.
+	content, addedLines = ensureRepositoryFileLines(d, "*.log\n.terraform/\n")
+	if content != "*.log\n.terraform/\n*.tfstate\n" || !reflect.DeepEqual(addedLines, []string{"*.tfstate"}) {
+		t.Fatalf("got %q with added lines %q after retry", content, addedLines)
+	}
+}
@@ -0,0 +1,281 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	lfsPointerVersion = "https://git-lfs.github.com/spec/v1"
+	lfsMediaType      = "application/vnd.git-lfs+json"
+)
+
+// lfsBatchRequest is a request to the Git LFS batch API,
+// see https://github.com/git-lfs/git-lfs/blob/main/docs/api/batch.md
+type lfsBatchRequest struct {
+	Operation string      `json:"operation"`
+	Transfers []string    `json:"transfers"`
+	Objects   []lfsObject `json:"objects"`
+}
+
+type lfsBatchResponse struct {
+	Objects []lfsObject `json:"objects"`
+}
+
+type lfsObject struct {
+	OID     string               `json:"oid"`
+	Size    int64                `json:"size"`
+	Actions map[string]lfsAction `json:"actions,omitempty"`
+	Error   *lfsObjectError      `json:"error,omitempty"`
+}
+
+type lfsAction struct {
+	Href   string            `json:"href"`
+	Header map[string]string `json:"header,omitempty"`
+}
+
+type lfsObjectError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// lfsPointer returns the content of the pointer file which is committed in place of an LFS object.
+func lfsPointer(oid string, size int64) string {
+	return fmt.Sprintf("version %s\noid sha256:%s\nsize %d\n", lfsPointerVersion, oid, size)
+}
+
+// parseLFSPointer returns the object id and size of the given LFS pointer file.
+// It returns false if the content isn't an LFS pointer.
+func parseLFSPointer(content []byte) (string, int64, bool) {
+	var oid string
+	var size int64 = -1
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	if len(lines) == 0 || lines[0] != "version "+lfsPointerVersion {
+		return "", 0, false
+	}
+
+	for _, line := range lines[1:] {
+		key, value := line, ""
+		if i := strings.Index(line, " "); i >= 0 {
+			key, value = line[:i], line[i+1:]
+		}
+		switch key {
+		case "oid":
+			oid = strings.TrimPrefix(value, "sha256:")
+		case "size":
+			parsedSize, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return "", 0, false
+			}
+			size = parsedSize
+		}
+	}
+
+	if oid == "" || size < 0 {
+		return "", 0, false
+	}
+	return oid, size, true
+}
+
+// isLFSTrackedPath returns true if the given `.gitattributes` content sets the `lfs` filter for the file path.
+// Like git, the last matching line wins.
+func isLFSTrackedPath(gitattributes, filePath string) bool {
+	tracked := false
+	for _, line := range strings.Split(gitattributes, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if !matchGitattributesPattern(fields[0], filePath) {
+			continue
+		}
+
+		for _, attribute := range fields[1:] {
+			switch {
+			case attribute == "filter=lfs":
+				tracked = true
+			case attribute == "-filter", attribute == "!filter", strings.HasPrefix(attribute, "filter="):
+				tracked = false
+			}
+		}
+	}
+	return tracked
+}
+
+// matchGitattributesPattern returns true if the given `.gitattributes` pattern matches the file path.
+// A pattern without a slash matches the name of the file in any directory,
+// otherwise it matches relative to the root of the repository.
+func matchGitattributesPattern(pattern, filePath string) bool {
+	if !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	var expression strings.Builder
+	expression.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			expression.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			expression.WriteString(".*")
+			i++
+		case c == '*':
+			expression.WriteString("[^/]*")
+		case c == '?':
+			expression.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				expression.WriteString(regexp.QuoteMeta(pattern[i:]))
+				i = len(pattern)
+				continue
+			}
+			class := pattern[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expression.WriteString("[" + class + "]")
+			i += end
+		default:
+			expression.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expression.WriteString("$")
+
+	matcher, err := regexp.Compile(expression.String())
+	if err != nil {
+		log.Printf("[WARN] ignoring invalid .gitattributes pattern %q: %v", pattern, err)
+		return false
+	}
+	return matcher.MatchString(filePath)
+}
+
+// uploadLFSObject uploads the given content to the LFS storage of the project
+// and returns the pointer file to commit in its place.
+// Objects which are already stored are not uploaded again.
+func uploadLFSObject(ctx context.Context, meta *Meta, project string, content []byte) (string, error) {
+	hash := sha256.Sum256(content)
+	object := lfsObject{
+		OID:  hex.EncodeToString(hash[:]),
+		Size: int64(len(content)),
+	}
+
+	p, _, err := meta.Client.Projects.GetProject(project, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get project %s: %v", project, err)
+	}
+	batchURL := strings.TrimSuffix(p.HTTPURLToRepo, ".git") + ".git/info/lfs/objects/batch"
+
+	var batchResponse lfsBatchResponse
+	err = doLFSRequest(ctx, meta, http.MethodPost, batchURL, nil, lfsBatchRequest{
+		Operation: "upload",
+		Transfers: []string{"basic"},
+		Objects:   []lfsObject{object},
+	}, &batchResponse)
+	if err != nil {
+		return "", err
+	}
+	if len(batchResponse.Objects) != 1 {
+		return "", fmt.Errorf("LFS batch API returned %d objects for a single upload", len(batchResponse.Objects))
+	}
+
+	result := batchResponse.Objects[0]
+	if result.Error != nil {
+		return "", fmt.Errorf("LFS batch API rejected object %s: %d %s", object.OID, result.Error.Code, result.Error.Message)
+	}
+
+	// without an upload action the object is already stored.
+	if upload, ok := result.Actions["upload"]; ok {
+		log.Printf("[DEBUG] uploading LFS object %s with %d bytes", object.OID, object.Size)
+		if err := doLFSUpload(ctx, meta, upload, content); err != nil {
+			return "", err
+		}
+		if verify, ok := result.Actions["verify"]; ok {
+			if err := doLFSRequest(ctx, meta, http.MethodPost, verify.Href, verify.Header, lfsObject{OID: object.OID, Size: object.Size}, nil); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	return lfsPointer(object.OID, object.Size), nil
+}
+
+// doLFSRequest sends a JSON request to the Git LFS API, authenticated with the configured token.
+func doLFSRequest(ctx context.Context, meta *Meta, method, href string, header map[string]string, body interface{}, v interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, href, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", lfsMediaType)
+	req.Header.Set("Content-Type", lfsMediaType)
+	if len(header) == 0 {
+		// The Git LFS API is authenticated like git over HTTP, where the username
+		// is ignored for personal and project access tokens.
+		req.SetBasicAuth("oauth2", meta.Config.Token)
+	}
+	for key, value := range header {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := meta.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkLFSResponse(resp); err != nil {
+		return err
+	}
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// doLFSUpload uploads the content of an LFS object as instructed by the upload action of the batch API.
+func doLFSUpload(ctx context.Context, meta *Meta, upload lfsAction, content []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, upload.Href, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = int64(len(content))
+	req.Header.Set("Content-Type", "application/octet-stream")
+	for key, value := range upload.Header {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := meta.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkLFSResponse(resp)
+}
+
+func checkLFSResponse(resp *http.Response) error {
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		return nil
+	}
+
+	message, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("%s %s: %d %s", resp.Request.Method, resp.Request.URL, resp.StatusCode, strings.TrimSpace(string(message)))
+}
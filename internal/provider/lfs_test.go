@@ -0,0 +1,53 @@
+package provider
+
+import (
+	"testing"
+)
+
+func TestLFSPointer(t *testing.T) {
+	pointer := lfsPointer("4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393", 12345)
+
+	oid, size, ok := parseLFSPointer([]byte(pointer))
+	if !ok {
+		t.Fatalf("failed to parse pointer %q", pointer)
+	}
+	if oid != "4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393" || size != 12345 {
+		t.Fatalf("got oid %q with size %d from pointer %q", oid, size, pointer)
+	}
+
+	for _, content := range []string{"", "meow", "version https://git-lfs.github.com/spec/v1\nsize 12\n", "version https://git-lfs.github.com/spec/v1\noid sha256:abc\nsize meow\n"} {
+		if _, _, ok := parseLFSPointer([]byte(content)); ok {
+			t.Fatalf("content %q was parsed as pointer", content)
+		}
+	}
+}
+
+func TestIsLFSTrackedPath(t *testing.T) {
+	gitattributes := `# binary assets
+*.png filter=lfs diff=lfs merge=lfs -text
+/assets/**/*.bin filter=lfs diff=lfs merge=lfs -text
+docs/*.pdf filter=lfs diff=lfs merge=lfs -text
+logo.png -filter
+`
+
+	cases := []struct {
+		givenFilePath   string
+		expectedTracked bool
+	}{
+		{givenFilePath: "image.png", expectedTracked: true},
+		{givenFilePath: "images/cat.png", expectedTracked: true},
+		{givenFilePath: "images/logo.png", expectedTracked: false},
+		{givenFilePath: "assets/firmware.bin", expectedTracked: true},
+		{givenFilePath: "assets/v1/firmware.bin", expectedTracked: true},
+		{givenFilePath: "other/assets/firmware.bin", expectedTracked: false},
+		{givenFilePath: "docs/manual.pdf", expectedTracked: true},
+		{givenFilePath: "docs/v1/manual.pdf", expectedTracked: false},
+		{givenFilePath: "meow.txt", expectedTracked: false},
+	}
+
+	for _, c := range cases {
+		if tracked := isLFSTrackedPath(gitattributes, c.givenFilePath); tracked != c.expectedTracked {
+			t.Fatalf("got tracked %v for %s; want %v", tracked, c.givenFilePath, c.expectedTracked)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package provider
+
+import (
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const (
+	defaultManagedBlockBeginMarker = "# BEGIN TERRAFORM MANAGED BLOCK"
+	defaultManagedBlockEndMarker   = "# END TERRAFORM MANAGED BLOCK"
+)
+
+// managedBlock is the region of a file between two marker lines, which is owned by a resource.
+// The rest of the file is left untouched.
+type managedBlock struct {
+	BeginMarker string
+	EndMarker   string
+}
+
+// managedBlockSchema returns the schema of the `managed_block` block of the repository file resource.
+func managedBlockSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:          schema.TypeList,
+		Optional:      true,
+		MaxItems:      1,
+		ConflictsWith: []string{"content_base64", "lfs"},
+		Description:   "If set, only the region between the begin and end marker lines of the file is managed and replaced with the `content`. The block is appended to the file if it's missing and the rest of the file is left untouched. Changes outside of the block are not detected. A file which already exists is always taken over, regardless of `if_exists`. On destroy with `on_destroy` set to `delete`, only the block is removed and the file is only deleted if nothing else is left.",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"begin_marker": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Default:     defaultManagedBlockBeginMarker,
+					Description: "The line which marks the begin of the managed block. Use a comment in the syntax of the file. Defaults to `" + defaultManagedBlockBeginMarker + "`.",
+				},
+				"end_marker": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Default:     defaultManagedBlockEndMarker,
+					Description: "The line which marks the end of the managed block. Use a comment in the syntax of the file. Defaults to `" + defaultManagedBlockEndMarker + "`.",
+				},
+			},
+		},
+	}
+}
+
+// expandManagedBlock returns the configured managed block or nil if the whole file is managed.
+func expandManagedBlock(v interface{}) *managedBlock {
+	blocks, ok := v.([]interface{})
+	if !ok || len(blocks) == 0 || blocks[0] == nil {
+		return nil
+	}
+
+	block := blocks[0].(map[string]interface{})
+	return &managedBlock{
+		BeginMarker: block["begin_marker"].(string),
+		EndMarker:   block["end_marker"].(string),
+	}
+}
+
+// locate returns the offsets of the begin marker line, the start and the end of the block content
+// and the end of the end marker line in the given file content.
+// Marker lines are matched regardless of surrounding whitespace.
+func (b *managedBlock) locate(content string) (int, int, int, int, bool) {
+	begin, contentStart := -1, -1
+	for offset := 0; offset < len(content); {
+		next := len(content)
+		if i := strings.IndexByte(content[offset:], '\n'); i >= 0 {
+			next = offset + i + 1
+		}
+
+		line := strings.TrimSpace(content[offset:next])
+		switch {
+		case begin < 0 && line == strings.TrimSpace(b.BeginMarker):
+			begin, contentStart = offset, next
+		case begin >= 0 && line == strings.TrimSpace(b.EndMarker):
+			return begin, contentStart, offset, next, true
+		}
+		offset = next
+	}
+	return 0, 0, 0, 0, false
+}
+
+// Extract returns the content of the block in the file content, if the file contains the block.
+func (b *managedBlock) Extract(content string) (string, bool) {
+	_, contentStart, contentEnd, _, ok := b.locate(content)
+	if !ok {
+		return "", false
+	}
+	return content[contentStart:contentEnd], true
+}
+
+// Render returns the block with the given content including its marker lines.
+func (b *managedBlock) Render(blockContent string) string {
+	if blockContent != "" && !strings.HasSuffix(blockContent, "\n") {
+		blockContent += "\n"
+	}
+	return b.BeginMarker + "\n" + blockContent + b.EndMarker + "\n"
+}
+
+// Upsert returns the file content with the block located by the previous markers replaced
+// with the given block content, or with the block appended if the file doesn't contain it yet.
+// The previous markers differ from the markers of the block if they have been changed.
+func (b *managedBlock) Upsert(content string, previous *managedBlock, blockContent string) string {
+	if begin, _, _, end, ok := previous.locate(content); ok {
+		return content[:begin] + b.Render(blockContent) + content[end:]
+	}
+
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return content + b.Render(blockContent)
+}
+
+// Remove returns the file content without the block.
+func (b *managedBlock) Remove(content string) string {
+	begin, _, _, end, ok := b.locate(content)
+	if !ok {
+		return content
+	}
+	return content[:begin] + content[end:]
+}
+
+// managedBlockStateContent returns the block content to store in the state.
+// The block content always ends with a newline in the file, therefore a content
+// which only differs by its trailing newline is kept as it is in the state.
+func managedBlockStateContent(blockContent, stateContent string) string {
+	if strings.TrimSuffix(blockContent, "\n") == strings.TrimSuffix(stateContent, "\n") {
+		return stateContent
+	}
+	return blockContent
+}
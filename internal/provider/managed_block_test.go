@@ -0,0 +1,82 @@
+package provider
+
+import (
+	"testing"
+)
+
+func TestManagedBlock(t *testing.T) {
+	block := &managedBlock{BeginMarker: "# BEGIN TERRAFORM", EndMarker: "# END TERRAFORM"}
+
+	cases := []struct {
+		givenFileContent      string
+		givenBlockContent     string
+		expectedFileContent   string
+		expectedBlockContent  string
+		expectedRemovedResult string
+	}{
+		{
+			givenFileContent:      "",
+			givenBlockContent:     "* @meow",
+			expectedFileContent:   "# BEGIN TERRAFORM\n* @meow\n# END TERRAFORM\n",
+			expectedBlockContent:  "* @meow\n",
+			expectedRemovedResult: "",
+		},
+		{
+			givenFileContent:      "*.log\n/build",
+			givenBlockContent:     "*.tfstate\n",
+			expectedFileContent:   "*.log\n/build\n# BEGIN TERRAFORM\n*.tfstate\n# END TERRAFORM\n",
+			expectedBlockContent:  "*.tfstate\n",
+			expectedRemovedResult: "*.log\n/build\n",
+		},
+		{
+			givenFileContent:      "*.log\n  # BEGIN TERRAFORM\n*.tmp\n# END TERRAFORM  \n/build\n",
+			givenBlockContent:     "*.tfstate\n*.tfvars\n",
+			expectedFileContent:   "*.log\n# BEGIN TERRAFORM\n*.tfstate\n*.tfvars\n# END TERRAFORM\n/build\n",
+			expectedBlockContent:  "*.tfstate\n*.tfvars\n",
+			expectedRemovedResult: "*.log\n/build\n",
+		},
+		{
+			givenFileContent:      "*.log\n# BEGIN TERRAFORM\n*.tmp\n# END TERRAFORM",
+			givenBlockContent:     "",
+			expectedFileContent:   "*.log\n# BEGIN TERRAFORM\n# END TERRAFORM\n",
+			expectedBlockContent:  "",
+			expectedRemovedResult: "*.log\n",
+		},
+	}
+
+	for _, c := range cases {
+		fileContent := block.Upsert(c.givenFileContent, block, c.givenBlockContent)
+		if fileContent != c.expectedFileContent {
+			t.Fatalf("got file content %q for %q; want %q", fileContent, c.givenFileContent, c.expectedFileContent)
+		}
+		if blockContent, ok := block.Extract(fileContent); !ok || blockContent != c.expectedBlockContent {
+			t.Fatalf("got block content %q (found: %v) from %q; want %q", blockContent, ok, fileContent, c.expectedBlockContent)
+		}
+		if removedResult := block.Remove(fileContent); removedResult != c.expectedRemovedResult {
+			t.Fatalf("got %q after removing the block from %q; want %q", removedResult, fileContent, c.expectedRemovedResult)
+		}
+	}
+
+	if _, ok := block.Extract("*.log\n# BEGIN TERRAFORM\n*.tmp\n"); ok {
+		t.Fatalf("found a block without end marker")
+	}
+}
+
+func TestManagedBlock_changedMarkers(t *testing.T) {
+	previous := &managedBlock{BeginMarker: "# BEGIN TERRAFORM", EndMarker: "# END TERRAFORM"}
+	block := &managedBlock{BeginMarker: "# >>> terraform", EndMarker: "# <<< terraform"}
+
+	fileContent := block.Upsert("*.log\n# BEGIN TERRAFORM\n*.tmp\n# END TERRAFORM\n/build\n", previous, "*.tfstate")
+	if expected := "*.log\n# >>> terraform\n*.tfstate\n# <<< terraform\n/build\n"; fileContent != expected {
+		t.Fatalf("got file content %q; want %q", fileContent, expected)
+	}
+}
+
+func TestManagedBlockStateContent(t *testing.T) {
+	if content := managedBlockStateContent("* @meow\n", "* @meow"); content != "* @meow" {
+		t.Fatalf("got %q; want the content of the state", content)
+	}
+	if content := managedBlockStateContent("* @purr\n", "* @meow"); content != "* @purr\n" {
+		t.Fatalf("got %q; want the content of the block", content)
+	}
+}
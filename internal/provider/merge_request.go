@@ -0,0 +1,293 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	gitlab "github.com/xanzy/go-gitlab"
+)
+
+// mergeRequestPollInterval is the interval in which a merge request is polled while waiting for it.
+const mergeRequestPollInterval = 10 * time.Second
+
+const (
+	// deliveryCommit commits changes directly to the branch.
+	deliveryCommit = "commit"
+	// deliveryMergeRequest commits changes to a source branch and opens a merge request against the branch.
+	deliveryMergeRequest = "merge_request"
+)
+
+var invalidBranchNameCharacters = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// generateMergeRequestSourceBranch returns the name of the source branch used for
+// changes of the given file path if no source branch is configured.
+func generateMergeRequestSourceBranch(branch, filePath string) string {
+	return fmt.Sprintf("terraform/%s/%s",
+		invalidBranchNameCharacters.ReplaceAllString(branch, "-"),
+		invalidBranchNameCharacters.ReplaceAllString(filePath, "-"),
+	)
+}
+
+// repositoryFileWriteBranches returns the branch to commit a change of the file to and
+// the branch to create it from, if it doesn't exist yet.
+// For the merge request delivery the change is committed to the source branch,
+// which is created from the start branch or the target branch.
+func repositoryFileWriteBranches(client *gitlab.Client, d *schema.ResourceData) (string, string, error) {
+	branch := d.Get("branch").(string)
+	startBranch := d.Get("start_branch").(string)
+	if d.Get("delivery").(string) != deliveryMergeRequest {
+		startBranch, err := repositoryStartBranch(client, d.Get("project").(string), branch, startBranch)
+		if err != nil {
+			return "", "", err
+		}
+		return branch, startBranch, nil
+	}
+
+	sourceBranch := d.Get("merge_request_source_branch").(string)
+	if sourceBranch == "" {
+		sourceBranch = generateMergeRequestSourceBranch(branch, d.Get("file_path").(string))
+		d.Set("merge_request_source_branch", sourceBranch)
+	}
+
+	exists, err := repositoryBranchExists(client, d.Get("project").(string), sourceBranch)
+	if err != nil {
+		return "", "", err
+	}
+	if exists {
+		return sourceBranch, "", nil
+	}
+
+	if startBranch == "" {
+		startBranch = branch
+	}
+	return sourceBranch, startBranch, nil
+}
+
+// repositoryFileReadBranch returns the branch to read the file from.
+// For the merge request delivery this is the source branch as long as it exists,
+// because the change isn't on the target branch until the merge request is merged.
+func repositoryFileReadBranch(client *gitlab.Client, d *schema.ResourceData) (string, error) {
+	branch := d.Get("branch").(string)
+	sourceBranch := d.Get("merge_request_source_branch").(string)
+	if d.Get("delivery").(string) != deliveryMergeRequest || sourceBranch == "" {
+		return branch, nil
+	}
+
+	exists, err := repositoryBranchExists(client, d.Get("project").(string), sourceBranch)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return sourceBranch, nil
+	}
+	return branch, nil
+}
+
+// repositoryStartBranch returns the start branch to create the branch from, if the branch doesn't exist yet.
+// GitLab rejects commits with a start branch to an existing branch, therefore no start branch is returned
+// once the branch has been created, e.g. by another resource.
+func repositoryStartBranch(client *gitlab.Client, project, branch, startBranch string) (string, error) {
+	if startBranch == "" {
+		return "", nil
+	}
+
+	exists, err := repositoryBranchExists(client, project, branch)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return "", nil
+	}
+	return startBranch, nil
+}
+
+func repositoryBranchExists(client *gitlab.Client, project, branch string) (bool, error) {
+	_, resp, err := client.Branches.GetBranch(project, branch)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// deliverRepositoryFileMergeRequest makes sure that there is an open merge request from the source branch
+// to the branch of the file. An already open merge request is reused, so that re-applies update it.
+// Depending on the configuration, the merge request is set to merge when its pipeline succeeds
+// and the merge is awaited.
+func deliverRepositoryFileMergeRequest(ctx context.Context, client *gitlab.Client, d *schema.ResourceData, timeout time.Duration) error {
+	project := d.Get("project").(string)
+	sourceBranch := d.Get("merge_request_source_branch").(string)
+	targetBranch := d.Get("branch").(string)
+
+	mergeRequests, _, err := client.MergeRequests.ListProjectMergeRequests(project, &gitlab.ListProjectMergeRequestsOptions{
+		State:        gitlab.String("opened"),
+		SourceBranch: gitlab.String(sourceBranch),
+		TargetBranch: gitlab.String(targetBranch),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list merge requests from %s to %s: %v", sourceBranch, targetBranch, err)
+	}
+
+	title := repositoryFileMergeRequestTitle(d)
+
+	var mergeRequest *gitlab.MergeRequest
+	if len(mergeRequests) > 0 {
+		mergeRequest = mergeRequests[0]
+		log.Printf("[DEBUG] reusing merge request !%d from %s to %s", mergeRequest.IID, sourceBranch, targetBranch)
+
+		if mergeRequest.Title != title {
+			mergeRequest, _, err = client.MergeRequests.UpdateMergeRequest(project, mergeRequest.IID, &gitlab.UpdateMergeRequestOptions{
+				Title: gitlab.String(title),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to update title of merge request from %s to %s: %v", sourceBranch, targetBranch, err)
+			}
+		}
+	} else {
+		mergeRequest, _, err = client.MergeRequests.CreateMergeRequest(project, &gitlab.CreateMergeRequestOptions{
+			Title:              gitlab.String(title),
+			SourceBranch:       gitlab.String(sourceBranch),
+			TargetBranch:       gitlab.String(targetBranch),
+			RemoveSourceBranch: gitlab.Bool(true),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create merge request from %s to %s: %v", sourceBranch, targetBranch, err)
+		}
+		log.Printf("[DEBUG] created merge request !%d from %s to %s", mergeRequest.IID, sourceBranch, targetBranch)
+	}
+
+	d.Set("merge_request_iid", mergeRequest.IID)
+	d.Set("merge_request_web_url", mergeRequest.WebURL)
+	d.Set("merge_request_merge_commit_sha", "")
+
+	if d.Get("merge_request_auto_merge").(bool) && !mergeRequest.MergeWhenPipelineSucceeds {
+		if err := autoMergeRepositoryFileMergeRequest(ctx, client, project, mergeRequest); err != nil {
+			return err
+		}
+	}
+
+	if d.Get("merge_request_wait_for_merge").(bool) {
+		return waitForRepositoryFileMergeRequest(ctx, client, d, project, mergeRequest.IID, timeout)
+	}
+	return nil
+}
+
+// autoMergeRepositoryFileMergeRequest sets the merge request to be merged when its pipeline succeeds.
+// GitLab needs to check if a new merge request can be merged at all before it accepts it,
+// therefore this check is awaited first.
+func autoMergeRepositoryFileMergeRequest(ctx context.Context, client *gitlab.Client, project string, mergeRequest *gitlab.MergeRequest) error {
+	for isMergeRequestMergeStatusPending(mergeRequest.MergeStatus) {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("interrupted while waiting for merge request %s to be checked", mergeRequest.WebURL)
+		case <-time.After(mergeRequestPollInterval):
+		}
+
+		checkedMergeRequest, _, err := client.MergeRequests.GetMergeRequest(project, mergeRequest.IID, nil)
+		if err != nil {
+			return fmt.Errorf("failed to get merge request %s: %v", mergeRequest.WebURL, err)
+		}
+		mergeRequest = checkedMergeRequest
+	}
+
+	_, _, err := client.MergeRequests.AcceptMergeRequest(project, mergeRequest.IID, &gitlab.AcceptMergeRequestOptions{
+		MergeWhenPipelineSucceeds: gitlab.Bool(true),
+		ShouldRemoveSourceBranch:  gitlab.Bool(true),
+		SHA:                       gitlab.String(mergeRequest.SHA),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set merge request %s to merge when pipeline succeeds: %v", mergeRequest.WebURL, err)
+	}
+	log.Printf("[DEBUG] set merge request !%d to merge when pipeline succeeds", mergeRequest.IID)
+	return nil
+}
+
+func isMergeRequestMergeStatusPending(mergeStatus string) bool {
+	return mergeStatus == "unchecked" || mergeStatus == "checking" || mergeStatus == "cannot_be_merged_recheck"
+}
+
+// waitForRepositoryFileMergeRequest blocks until the merge request is merged, closed, its pipeline
+// failed or the timeout is exceeded. The merge commit is recorded once the merge request is merged.
+func waitForRepositoryFileMergeRequest(ctx context.Context, client *gitlab.Client, d *schema.ResourceData, project string, iid int, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		mergeRequest, _, err := client.MergeRequests.GetMergeRequest(project, iid, nil)
+		if err != nil {
+			return fmt.Errorf("failed to get merge request !%d: %v", iid, err)
+		}
+
+		switch {
+		case mergeRequest.State == "merged":
+			log.Printf("[DEBUG] merge request !%d has been merged", iid)
+			d.Set("merge_request_merge_commit_sha", mergeRequestMergeCommitSHA(mergeRequest))
+			return nil
+		case mergeRequest.State == "closed":
+			return fmt.Errorf("merge request %s has been closed without being merged", mergeRequest.WebURL)
+		case mergeRequest.HeadPipeline != nil && mergeRequest.HeadPipeline.SHA == mergeRequest.SHA && isPipelineStatusFailed(mergeRequest.HeadPipeline.Status):
+			// the head pipeline may still belong to the previous commit right after a new one has been pushed.
+			return fmt.Errorf("pipeline %s of merge request %s has %s", mergeRequest.HeadPipeline.WebURL, mergeRequest.WebURL, mergeRequest.HeadPipeline.Status)
+		}
+
+		log.Printf("[DEBUG] waiting for merge request !%d to be merged, it's currently %s", iid, mergeRequest.State)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timeout while waiting for merge request %s to be merged", mergeRequest.WebURL)
+		case <-time.After(mergeRequestPollInterval):
+		}
+	}
+}
+
+func isPipelineStatusFailed(status string) bool {
+	return status == "failed" || status == "canceled"
+}
+
+// mergeRequestMergeCommitSHA returns the commit which brought the changes of the merge request
+// to the target branch. For fast-forward merges there is no merge commit,
+// but the head commit of the merge request itself.
+func mergeRequestMergeCommitSHA(mergeRequest *gitlab.MergeRequest) string {
+	if mergeRequest.MergeCommitSHA != "" {
+		return mergeRequest.MergeCommitSHA
+	}
+	return mergeRequest.SHA
+}
+
+// refreshRepositoryFileMergeRequest records the merge commit of the last merge request once it has been merged.
+func refreshRepositoryFileMergeRequest(client *gitlab.Client, d *schema.ResourceData) error {
+	iid := d.Get("merge_request_iid").(int)
+	if d.Get("delivery").(string) != deliveryMergeRequest || iid == 0 || d.Get("merge_request_merge_commit_sha").(string) != "" {
+		return nil
+	}
+
+	mergeRequest, resp, err := client.MergeRequests.GetMergeRequest(d.Get("project").(string), iid, nil)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil
+		}
+		return err
+	}
+
+	d.Set("merge_request_web_url", mergeRequest.WebURL)
+	if mergeRequest.State == "merged" {
+		d.Set("merge_request_merge_commit_sha", mergeRequestMergeCommitSHA(mergeRequest))
+	}
+	return nil
+}
+
+// repositoryFileMergeRequestTitle returns the configured merge request title
+// or the first line of the commit message.
+func repositoryFileMergeRequestTitle(d *schema.ResourceData) string {
+	if title, ok := d.GetOk("merge_request_title"); ok {
+		return title.(string)
+	}
+	return strings.SplitN(d.Get("commit_message").(string), "\n", 2)[0]
+}
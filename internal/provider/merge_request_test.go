@@ -0,0 +1,59 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	gitlab "github.com/xanzy/go-gitlab"
+)
+
+func TestGenerateMergeRequestSourceBranch(t *testing.T) {
+	cases := []struct {
+		givenBranch          string
+		givenFilePath        string
+		expectedSourceBranch string
+	}{
+		{
+			givenBranch:          "main",
+			givenFilePath:        "meow.txt",
+			expectedSourceBranch: "terraform/main/meow.txt",
+		},
+		{
+			givenBranch:          "release/1.0",
+			givenFilePath:        "launch codes/secret:codes.txt",
+			expectedSourceBranch: "terraform/release-1.0/launch-codes-secret-codes.txt",
+		},
+	}
+
+	for _, c := range cases {
+		if sourceBranch := generateMergeRequestSourceBranch(c.givenBranch, c.givenFilePath); sourceBranch != c.expectedSourceBranch {
+			t.Fatalf("got source branch %q; want %q", sourceBranch, c.expectedSourceBranch)
+		}
+	}
+}
+
+func TestRepositoryFileMergeRequestTitle(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceGitlabRepositoryFile().Schema, map[string]interface{}{
+		"commit_message": "feature: add launch codes\n\nThe launch codes are required.",
+	})
+	if title := repositoryFileMergeRequestTitle(d); title != "feature: add launch codes" {
+		t.Fatalf("got title %q; want the first line of the commit message", title)
+	}
+
+	d.Set("merge_request_title", "Launch codes")
+	if title := repositoryFileMergeRequestTitle(d); title != "Launch codes" {
+		t.Fatalf("got title %q; want the configured title", title)
+	}
+}
+
+func TestMergeRequestMergeCommitSHA(t *testing.T) {
+	mergeRequest := &gitlab.MergeRequest{SHA: "head", MergeCommitSHA: "merge"}
+	if sha := mergeRequestMergeCommitSHA(mergeRequest); sha != "merge" {
+		t.Fatalf("got %q; want the merge commit", sha)
+	}
+
+	mergeRequest.MergeCommitSHA = ""
+	if sha := mergeRequestMergeCommitSHA(mergeRequest); sha != "head" {
+		t.Fatalf("got %q; want the head commit of a fast-forward merge", sha)
+	}
+}
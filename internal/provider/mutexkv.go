@@ -0,0 +1,60 @@
+package provider
+
+import (
+	"fmt"
+	"log"
+	"sync"
+)
+
+// repositoryBranchMutexKV serializes all writes to the same branch of a project.
+// The GitLab API rejects a change to a branch if the branch has been moved
+// while the change was being processed, thus concurrent writes are likely to fail.
+var repositoryBranchMutexKV = newMutexKV()
+
+// mutexKV is a simple key/value store for arbitrary mutexes. It can be used to
+// serialize changes across arbitrary collaborators that share knowledge of the
+// keys they must serialize on.
+type mutexKV struct {
+	lock  sync.Mutex
+	store map[string]*sync.Mutex
+}
+
+// Lock locks the mutex for the given key. Caller is responsible for calling Unlock
+// for the same key.
+func (m *mutexKV) Lock(key string) {
+	log.Printf("[DEBUG] Locking %q", key)
+	m.get(key).Lock()
+	log.Printf("[DEBUG] Locked %q", key)
+}
+
+// Unlock unlocks the mutex for the given key. Caller must have called Lock for the same key first.
+func (m *mutexKV) Unlock(key string) {
+	log.Printf("[DEBUG] Unlocking %q", key)
+	m.get(key).Unlock()
+	log.Printf("[DEBUG] Unlocked %q", key)
+}
+
+// get returns a mutex for the given key, no guarantee of its lock status.
+func (m *mutexKV) get(key string) *sync.Mutex {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	mutex, ok := m.store[key]
+	if !ok {
+		mutex = &sync.Mutex{}
+		m.store[key] = mutex
+	}
+	return mutex
+}
+
+// newMutexKV returns a properly initialized mutexKV.
+func newMutexKV() *mutexKV {
+	return &mutexKV{
+		store: make(map[string]*sync.Mutex),
+	}
+}
+
+// repositoryBranchLockKey returns the key to lock on for writes to the given branch of the given project.
+// Note that a project referenced by its ID and by its path results in different keys.
+func repositoryBranchLockKey(project, branch string) string {
+	return fmt.Sprintf("%s:%s", project, branch)
+}
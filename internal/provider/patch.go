@@ -0,0 +1,293 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// devNull is the path of a missing file in a unified diff, i.e. of the old side of a created
+// or the new side of a deleted file.
+const devNull = "/dev/null"
+
+var patchHunkHeaderPattern = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// filePatch is the part of a unified diff which changes a single file.
+type filePatch struct {
+	OldPath string
+	NewPath string
+	Hunks   []patchHunk
+}
+
+// patchHunk is a hunk of a unified diff. Lines are prefixed with their operation, i.e. ` `, `-` or `+`.
+type patchHunk struct {
+	OldStart     int
+	NewStart     int
+	Lines        []string
+	OldNoNewline bool
+	NewNoNewline bool
+}
+
+// Path returns the path of the file to read and write, which is the new path unless the file is deleted.
+// Like with `diff -u file.orig file`, the old path may differ, but renames aren't supported.
+func (p *filePatch) Path() string {
+	if p.NewPath == devNull {
+		return p.OldPath
+	}
+	return p.NewPath
+}
+
+// Reverse returns the patch which reverts the patch.
+func (p *filePatch) Reverse() *filePatch {
+	reversed := &filePatch{OldPath: p.NewPath, NewPath: p.OldPath}
+	for _, hunk := range p.Hunks {
+		reversedHunk := patchHunk{
+			OldStart:     hunk.NewStart,
+			NewStart:     hunk.OldStart,
+			OldNoNewline: hunk.NewNoNewline,
+			NewNoNewline: hunk.OldNoNewline,
+		}
+		for _, line := range hunk.Lines {
+			switch line[0] {
+			case '-':
+				line = "+" + line[1:]
+			case '+':
+				line = "-" + line[1:]
+			}
+			reversedHunk.Lines = append(reversedHunk.Lines, line)
+		}
+		reversed.Hunks = append(reversed.Hunks, reversedHunk)
+	}
+	return reversed
+}
+
+// parseUnifiedDiff returns the file patches of a unified diff, e.g. created by `git diff` or `diff -u`.
+// Lines which don't belong to a file header or a hunk, like `diff --git` or `index` lines, are ignored.
+func parseUnifiedDiff(diff string) ([]*filePatch, error) {
+	lines := strings.Split(strings.TrimSuffix(diff, "\n"), "\n")
+
+	var patches []*filePatch
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSuffix(lines[i], "\r")
+		switch {
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			patches = append(patches, &filePatch{
+				OldPath: parsePatchPath(line[4:]),
+				NewPath: parsePatchPath(strings.TrimSuffix(lines[i+1], "\r")[4:]),
+			})
+			i++
+		case strings.HasPrefix(line, "@@ "):
+			if len(patches) == 0 {
+				return nil, fmt.Errorf("line %d: hunk without file header", i+1)
+			}
+			hunk, oldLines, newLines, err := parsePatchHunkHeader(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", i+1, err)
+			}
+
+			for oldLines > 0 || newLines > 0 {
+				i++
+				if i >= len(lines) {
+					return nil, fmt.Errorf("line %d: unexpected end of hunk", i)
+				}
+				hunkLine := lines[i]
+				if hunkLine == "" {
+					// some tools strip the trailing space of empty context lines.
+					hunkLine = " "
+				}
+				switch hunkLine[0] {
+				case ' ':
+					oldLines--
+					newLines--
+				case '-':
+					oldLines--
+				case '+':
+					newLines--
+				case '\\':
+					setPatchHunkNoNewline(&hunk)
+					continue
+				default:
+					return nil, fmt.Errorf("line %d: unexpected line in hunk: %q", i+1, lines[i])
+				}
+				if oldLines < 0 || newLines < 0 {
+					return nil, fmt.Errorf("line %d: hunk is longer than stated in its header", i+1)
+				}
+				hunk.Lines = append(hunk.Lines, hunkLine)
+			}
+			if i+1 < len(lines) && strings.HasPrefix(lines[i+1], `\`) {
+				i++
+				setPatchHunkNoNewline(&hunk)
+			}
+
+			patch := patches[len(patches)-1]
+			patch.Hunks = append(patch.Hunks, hunk)
+		}
+	}
+
+	if len(patches) == 0 {
+		return nil, fmt.Errorf("the diff doesn't contain any file")
+	}
+	for _, patch := range patches {
+		if len(patch.Hunks) == 0 {
+			return nil, fmt.Errorf("the diff of file %s doesn't contain any hunk", patch.Path())
+		}
+	}
+	return patches, nil
+}
+
+// parsePatchPath returns the path of a file header without the `a/` or `b/` prefix of git and without a timestamp.
+func parsePatchPath(s string) string {
+	if i := strings.IndexByte(s, '\t'); i >= 0 {
+		s = s[:i]
+	}
+	if s == devNull {
+		return s
+	}
+	if strings.HasPrefix(s, "a/") || strings.HasPrefix(s, "b/") {
+		return s[2:]
+	}
+	return s
+}
+
+func parsePatchHunkHeader(line string) (patchHunk, int, int, error) {
+	match := patchHunkHeaderPattern.FindStringSubmatch(line)
+	if match == nil {
+		return patchHunk{}, 0, 0, fmt.Errorf("invalid hunk header %q", line)
+	}
+
+	numbers := make([]int, 4)
+	for i, s := range match[1:] {
+		numbers[i] = 1
+		if s != "" {
+			numbers[i], _ = strconv.Atoi(s)
+		}
+	}
+	return patchHunk{OldStart: numbers[0], NewStart: numbers[2]}, numbers[1], numbers[3], nil
+}
+
+// setPatchHunkNoNewline marks the side of the last line of the hunk as not ending with a newline.
+func setPatchHunkNoNewline(hunk *patchHunk) {
+	if len(hunk.Lines) == 0 {
+		return
+	}
+	switch hunk.Lines[len(hunk.Lines)-1][0] {
+	case ' ':
+		hunk.OldNoNewline, hunk.NewNoNewline = true, true
+	case '-':
+		hunk.OldNoNewline = true
+	case '+':
+		hunk.NewNoNewline = true
+	}
+}
+
+// Apply returns the content with the patch applied.
+// A hunk is searched near its stated position, in case lines have been added or removed before it.
+// With a fuzz factor, up to that many leading and trailing context lines of a hunk may be ignored
+// if the hunk doesn't apply otherwise, like with GNU patch.
+func (p *filePatch) Apply(content string, fuzz int) (string, error) {
+	newline := content == "" || strings.HasSuffix(content, "\n")
+	var lines []string
+	if content != "" {
+		lines = strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	}
+
+	// offset is the number of lines the previous hunks have added or removed and
+	// minPosition the position after the previous hunk, because hunks must not overlap.
+	offset, minPosition := 0, 0
+	for i, hunk := range p.Hunks {
+		applied := false
+		for f := 0; f <= fuzz && !applied; f++ {
+			oldLines, newLines, leading := hunk.fuzzedLines(f)
+
+			position := hunk.OldStart - 1 + offset + leading
+			if !hunk.hasOldLines() {
+				// a hunk which only adds lines states the line after which they are added.
+				position++
+			}
+
+			if match, ok := findPatchLines(lines, oldLines, position, minPosition); ok {
+				lines = append(lines[:match:match], append(append([]string{}, newLines...), lines[match+len(oldLines):]...)...)
+				offset += match - position + len(newLines) - len(oldLines)
+				minPosition = match + len(newLines)
+				applied = true
+			}
+		}
+		if !applied {
+			return "", fmt.Errorf("hunk #%d at line %d of file %s doesn't apply", i+1, hunk.OldStart, p.Path())
+		}
+
+		if hunk.NewNoNewline {
+			newline = false
+		} else if hunk.OldNoNewline {
+			newline = true
+		}
+	}
+
+	if len(lines) == 0 {
+		return "", nil
+	}
+	result := strings.Join(lines, "\n")
+	if newline {
+		result += "\n"
+	}
+	return result, nil
+}
+
+func (h *patchHunk) hasOldLines() bool {
+	for _, line := range h.Lines {
+		if line[0] != '+' {
+			return true
+		}
+	}
+	return false
+}
+
+// fuzzedLines returns the old and new lines of the hunk without up to fuzz leading and trailing
+// context lines and the number of removed leading lines.
+func (h *patchHunk) fuzzedLines(fuzz int) ([]string, []string, int) {
+	start, end := 0, len(h.Lines)
+	for start < fuzz && start < end && h.Lines[start][0] == ' ' {
+		start++
+	}
+	for len(h.Lines)-end < fuzz && end > start && h.Lines[end-1][0] == ' ' {
+		end--
+	}
+
+	var oldLines, newLines []string
+	for _, line := range h.Lines[start:end] {
+		if line[0] != '+' {
+			oldLines = append(oldLines, line[1:])
+		}
+		if line[0] != '-' {
+			newLines = append(newLines, line[1:])
+		}
+	}
+	return oldLines, newLines, start
+}
+
+// findPatchLines returns the position of the lines in the content lines, which is closest to the given position
+// and not before the minimum position.
+func findPatchLines(lines, patchLines []string, position, minPosition int) (int, bool) {
+	matches := func(at int) bool {
+		if at < minPosition || at+len(patchLines) > len(lines) {
+			return false
+		}
+		for i, line := range patchLines {
+			if lines[at+i] != line {
+				return false
+			}
+		}
+		return true
+	}
+
+	for distance := 0; position-distance >= minPosition || position+distance <= len(lines); distance++ {
+		if matches(position - distance) {
+			return position - distance, true
+		}
+		if matches(position + distance) {
+			return position + distance, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,119 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFilePatch_apply(t *testing.T) {
+	cases := []struct {
+		name            string
+		diff            string
+		fuzz            int
+		givenContent    string
+		expectedContent string
+		expectedError   bool
+	}{
+		{
+			name:            "exact",
+			diff:            "--- a/client.go\n+++ b/client.go\n@@ -2,3 +2,3 @@\n b\n-timeout = 10\n+timeout = 30\n c\n",
+			givenContent:    "a\nb\ntimeout = 10\nc\nd\n",
+			expectedContent: "a\nb\ntimeout = 30\nc\nd\n",
+		},
+		{
+			name:            "offset",
+			diff:            "--- a/client.go\n+++ b/client.go\n@@ -2,3 +2,3 @@\n b\n-timeout = 10\n+timeout = 30\n c\n",
+			givenContent:    "new\nlines\na\nb\ntimeout = 10\nc\nd\n",
+			expectedContent: "new\nlines\na\nb\ntimeout = 30\nc\nd\n",
+		},
+		{
+			name:          "changed context without fuzz",
+			diff:          "--- a/client.go\n+++ b/client.go\n@@ -2,3 +2,3 @@\n b\n-timeout = 10\n+timeout = 30\n c\n",
+			givenContent:  "a\nB\ntimeout = 10\nc\nd\n",
+			expectedError: true,
+		},
+		{
+			name:            "changed context with fuzz",
+			diff:            "--- a/client.go\n+++ b/client.go\n@@ -2,3 +2,3 @@\n b\n-timeout = 10\n+timeout = 30\n c\n",
+			fuzz:            1,
+			givenContent:    "a\nB\ntimeout = 10\nc\nd\n",
+			expectedContent: "a\nB\ntimeout = 30\nc\nd\n",
+		},
+		{
+			name:          "changed line",
+			diff:          "--- a/client.go\n+++ b/client.go\n@@ -2,3 +2,3 @@\n b\n-timeout = 10\n+timeout = 30\n c\n",
+			fuzz:          2,
+			givenContent:  "a\nb\ntimeout = 20\nc\nd\n",
+			expectedError: true,
+		},
+		{
+			name:            "multiple hunks",
+			diff:            "--- client.go.orig\t2021-09-01 12:00:00\n+++ client.go\t2021-09-01 12:00:00\n@@ -1,2 +1,3 @@\n a\n+a2\n b\n@@ -4,2 +5,2 @@\n d\n-e\n+E\n",
+			givenContent:    "a\nb\nc\nd\ne\n",
+			expectedContent: "a\na2\nb\nc\nd\nE\n",
+		},
+		{
+			name:            "new file",
+			diff:            "diff --git a/NOTICE b/NOTICE\nnew file mode 100644\n--- /dev/null\n+++ b/NOTICE\n@@ -0,0 +1,2 @@\n+patched\n+by meow\n",
+			givenContent:    "",
+			expectedContent: "patched\nby meow\n",
+		},
+		{
+			name:            "no newline at end of file",
+			diff:            "--- a/VERSION\n+++ b/VERSION\n@@ -1 +1 @@\n-1.0.0\n\\ No newline at end of file\n+1.0.1\n",
+			givenContent:    "1.0.0",
+			expectedContent: "1.0.1\n",
+		},
+	}
+
+	for _, c := range cases {
+		patches, err := parseUnifiedDiff(c.diff)
+		if err != nil {
+			t.Fatalf("%s: failed to parse diff: %v", c.name, err)
+		}
+		if len(patches) != 1 {
+			t.Fatalf("%s: got %d file patches; want 1", c.name, len(patches))
+		}
+
+		content, err := patches[0].Apply(c.givenContent, c.fuzz)
+		if (err != nil) != c.expectedError {
+			t.Fatalf("%s: got error %v; want error: %v", c.name, err, c.expectedError)
+		}
+		if err != nil {
+			continue
+		}
+		if content != c.expectedContent {
+			t.Fatalf("%s: got %q; want %q", c.name, content, c.expectedContent)
+		}
+
+		reverted, err := patches[0].Reverse().Apply(content, c.fuzz)
+		if err != nil || reverted != c.givenContent {
+			t.Fatalf("%s: got %q (error: %v) after reverting; want %q", c.name, reverted, err, c.givenContent)
+		}
+	}
+}
+
+func TestParseUnifiedDiff(t *testing.T) {
+	patches, err := parseUnifiedDiff("diff --git a/a.txt b/a.txt\nindex 1..2 100644\n--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-a\n+A\ndiff --git a/b.txt b/b.txt\ndeleted file mode 100644\n--- a/b.txt\n+++ /dev/null\n@@ -1,2 +0,0 @@\n-b\n-\n")
+	if err != nil {
+		t.Fatalf("failed to parse diff: %v", err)
+	}
+	if len(patches) != 2 || patches[0].Path() != "a.txt" || patches[1].Path() != "b.txt" || patches[1].NewPath != devNull {
+		t.Fatalf("got unexpected file patches %+v", patches)
+	}
+	if content, err := patches[1].Apply("b\n\n", 0); err != nil || content != "" {
+		t.Fatalf("got %q (error: %v) after deleting all lines; want an empty content", content, err)
+	}
+
+	for _, diff := range []string{
+		"",
+		"just some text\n",
+		"--- a/a.txt\n+++ b/a.txt\n",
+		"--- a/a.txt\n+++ b/a.txt\n@@ -1,2 +1,2 @@\n-a\n+A\n",
+		"--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n*a\n",
+	} {
+		if _, err := parseUnifiedDiff(diff); err == nil {
+			t.Fatalf("expected error for invalid diff %q", strings.ReplaceAll(diff, "\n", `\n`))
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package provider
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	gitlab "github.com/xanzy/go-gitlab"
+)
+
+// skipCIMarker in a commit message prevents GitLab from starting a pipeline for the commit.
+const skipCIMarker = "[skip ci]"
+
+var skipCIMarkerPattern = regexp.MustCompile(`(?i)\[(skip ci|ci skip)\]`)
+
+// deferredPipelines keeps track of the branches whose commits skipped CI, so that a single pipeline
+// can be started for the last commit to each of them. Terraform doesn't tell the provider which commit
+// is the last one of a run, therefore the pipelines of a branch are started once no other write
+// to the branch is pending, i.e. once the resources waiting for the branch are done.
+type deferredPipelines struct {
+	lock     sync.Mutex
+	writes   map[string]int
+	branches map[string]deferredPipeline
+}
+
+// deferredPipeline is a branch for which a pipeline has to be started.
+type deferredPipeline struct {
+	client  *gitlab.Client
+	lockKey string
+	project string
+	branch  string
+}
+
+func newDeferredPipelines() *deferredPipelines {
+	return &deferredPipelines{writes: make(map[string]int), branches: make(map[string]deferredPipeline)}
+}
+
+// repositoryPipelines are the deferred pipelines of all provider configurations.
+var repositoryPipelines = newDeferredPipelines()
+
+// Begin records a pending write to the branch with the given lock key.
+func (p *deferredPipelines) Begin(lockKey string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.writes[lockKey]++
+}
+
+// Defer records that a pipeline has to be started for the branch after its last commit.
+// The lock key is the one of the write, which may differ from the branch, e.g. for a merge request source branch.
+func (p *deferredPipelines) Defer(client *gitlab.Client, lockKey, project, branch string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	log.Printf("[DEBUG] deferring pipeline for branch %s until the last commit", branch)
+	p.branches[repositoryBranchLockKey(project, branch)] = deferredPipeline{client: client, lockKey: lockKey, project: project, branch: branch}
+}
+
+// End records that a write to the branch with the given lock key is done and starts a single pipeline
+// for the current head of each deferred branch of the writes, unless other writes are still pending.
+func (p *deferredPipelines) End(lockKey string) error {
+	var errs []string
+	for _, deferred := range p.finish(lockKey) {
+		pipeline, _, err := deferred.client.Pipelines.CreatePipeline(deferred.project, &gitlab.CreatePipelineOptions{
+			Ref: gitlab.String(deferred.branch),
+		})
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("failed to create pipeline for branch %s: %v", deferred.branch, err))
+		} else {
+			log.Printf("[DEBUG] created pipeline %d for branch %s", pipeline.ID, deferred.branch)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// finish records that a write is done and returns and forgets the deferred pipelines to start,
+// if it has been the last pending write.
+func (p *deferredPipelines) finish(lockKey string) []deferredPipeline {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.writes[lockKey]--
+	if p.writes[lockKey] > 0 {
+		log.Printf("[DEBUG] other writes to %s are pending, deferring pipelines further", lockKey)
+		return nil
+	}
+	delete(p.writes, lockKey)
+
+	var pipelines []deferredPipeline
+	for key, deferred := range p.branches {
+		if deferred.lockKey == lockKey {
+			pipelines = append(pipelines, deferred)
+			delete(p.branches, key)
+		}
+	}
+	return pipelines
+}
+
+// lockRepositoryBranch locks the branch for a write and records the write as pending,
+// so that deferred pipelines aren't started before it's done.
+func lockRepositoryBranch(meta *Meta, lockKey string) {
+	meta.Pipelines.Begin(lockKey)
+	repositoryBranchMutexKV.Lock(lockKey)
+}
+
+// unlockRepositoryBranch starts the deferred pipelines of the branch, if no other write is pending,
+// and unlocks the branch. The pipelines are started while the branch is still locked,
+// so that they run for the last commit.
+// A failure to start the pipelines is only a warning, because the commits themselves succeeded.
+func unlockRepositoryBranch(meta *Meta, lockKey string) diag.Diagnostics {
+	defer repositoryBranchMutexKV.Unlock(lockKey)
+	if err := meta.Pipelines.End(lockKey); err != nil {
+		return diag.Diagnostics{
+			{
+				Severity: diag.Warning,
+				Summary:  "Failed to start deferred pipelines",
+				Detail:   fmt.Sprintf("The commits skipped CI, so that a single pipeline runs for the last commit to each branch, but it couldn't be started: %v", err),
+			},
+		}
+	}
+	return nil
+}
+
+// commitSkipCISchema returns the schema of the `skip_ci` attribute, which is shared by the resources.
+func commitSkipCISchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeBool,
+		Optional:    true,
+		Description: "If the commits should be marked with `[skip ci]`, so that GitLab doesn't start pipelines for them. Defaults to the `skip_ci` and `skip_ci_except_last` settings of the provider.",
+	}
+}
+
+// repositoryCommitSkipCI returns if commits of the resource should skip CI and if a pipeline
+// should be started for the branch after its last commit instead.
+// The `skip_ci` attribute of the resource takes precedence over the provider configuration.
+func repositoryCommitSkipCI(meta *Meta, d *schema.ResourceData) (bool, bool) {
+	if skipCI, ok := d.GetOkExists("skip_ci"); ok {
+		return skipCI.(bool), false
+	}
+	if meta == nil || meta.Config == nil {
+		return false, false
+	}
+	if meta.Config.SkipCIExceptLast {
+		return true, true
+	}
+	return meta.Config.SkipCI, false
+}
+
+// deferRepositoryPipeline defers the pipeline for the commit to the branch, if its CI has been skipped
+// only to run a single pipeline for the last commit to the branch.
+// It must be called while the branch of the resource is locked with lockRepositoryBranch.
+func deferRepositoryPipeline(meta *Meta, d *schema.ResourceData, branch string) {
+	if _, deferPipeline := repositoryCommitSkipCI(meta, d); deferPipeline {
+		project := d.Get("project").(string)
+		meta.Pipelines.Defer(meta.Client, repositoryBranchLockKey(project, d.Get("branch").(string)), project, branch)
+	}
+}
+
+// appendSkipCIMarker appends the skip CI marker to the first line of the commit message,
+// unless the message already contains a marker.
+func appendSkipCIMarker(message string) string {
+	if skipCIMarkerPattern.MatchString(message) {
+		return message
+	}
+
+	lines := strings.SplitN(message, "\n", 2)
+	lines[0] = strings.TrimSpace(strings.TrimSpace(lines[0]) + " " + skipCIMarker)
+	return strings.Join(lines, "\n")
+}
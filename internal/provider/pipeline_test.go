@@ -0,0 +1,101 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestAppendSkipCIMarker(t *testing.T) {
+	cases := []struct {
+		givenMessage    string
+		expectedMessage string
+	}{
+		{
+			givenMessage:    "feature: add launch codes",
+			expectedMessage: "feature: add launch codes [skip ci]",
+		},
+		{
+			givenMessage:    "feature: add launch codes \n\nThe codes are secret.",
+			expectedMessage: "feature: add launch codes [skip ci]\n\nThe codes are secret.",
+		},
+		{
+			givenMessage:    "feature: add launch codes [CI SKIP]",
+			expectedMessage: "feature: add launch codes [CI SKIP]",
+		},
+		{
+			givenMessage:    "",
+			expectedMessage: "[skip ci]",
+		},
+	}
+
+	for _, c := range cases {
+		if message := appendSkipCIMarker(c.givenMessage); message != c.expectedMessage {
+			t.Fatalf("got commit message %q for %q; want %q", message, c.givenMessage, c.expectedMessage)
+		}
+	}
+}
+
+func TestRepositoryCommitSkipCI(t *testing.T) {
+	raw := map[string]interface{}{
+		"project":        "42",
+		"branch":         "main",
+		"file_path":      "meow.txt",
+		"commit_message": "feature: add launch codes",
+	}
+	d := schema.TestResourceDataRaw(t, resourceGitlabRepositoryFile().Schema, raw)
+
+	raw["skip_ci"] = false
+	dRun := schema.TestResourceDataRaw(t, resourceGitlabRepositoryFile().Schema, raw)
+
+	cases := []struct {
+		givenConfig           *Config
+		givenResourceData     *schema.ResourceData
+		expectedSkipCI        bool
+		expectedDeferPipeline bool
+	}{
+		{givenConfig: &Config{}, givenResourceData: d, expectedSkipCI: false, expectedDeferPipeline: false},
+		{givenConfig: &Config{SkipCI: true}, givenResourceData: d, expectedSkipCI: true, expectedDeferPipeline: false},
+		{givenConfig: &Config{SkipCI: true, SkipCIExceptLast: true}, givenResourceData: d, expectedSkipCI: true, expectedDeferPipeline: true},
+		{givenConfig: &Config{SkipCIExceptLast: true}, givenResourceData: dRun, expectedSkipCI: false, expectedDeferPipeline: false},
+	}
+
+	for i, c := range cases {
+		skipCI, deferPipeline := repositoryCommitSkipCI(&Meta{Config: c.givenConfig}, c.givenResourceData)
+		if skipCI != c.expectedSkipCI || deferPipeline != c.expectedDeferPipeline {
+			t.Fatalf("case %d: got skip CI %v and defer pipeline %v; want %v and %v", i, skipCI, deferPipeline, c.expectedSkipCI, c.expectedDeferPipeline)
+		}
+	}
+
+	expectedMessage := "feature: add launch codes [skip ci]"
+	if message := commitMessage(&Meta{Config: &Config{SkipCI: true}}, d, commitActionUpdate, "meow.txt"); message != expectedMessage {
+		t.Fatalf("got commit message %q; want %q", message, expectedMessage)
+	}
+}
+
+func TestDeferredPipelinesDefer(t *testing.T) {
+	p := newDeferredPipelines()
+	p.Begin("1:main")
+	p.Begin("1:main")
+	p.Defer(nil, "1:main", "1", "main")
+	p.Defer(nil, "1:main", "1", "main")
+	p.Defer(nil, "1:main", "1", "terraform/main/meow.txt")
+	p.Begin("2:main")
+	p.Defer(nil, "2:main", "2", "main")
+
+	// the pipelines are deferred as long as other writes to the branch are pending.
+	if pipelines := p.finish("1:main"); len(pipelines) != 0 {
+		t.Fatalf("got %d pipelines to start while a write is pending; want 0", len(pipelines))
+	}
+
+	// a single pipeline is started for each branch, regardless of the number of its commits.
+	if pipelines := p.finish("1:main"); len(pipelines) != 2 {
+		t.Fatalf("got %d pipelines to start after the last write; want 2", len(pipelines))
+	}
+	if pipelines := p.finish("2:main"); len(pipelines) != 1 {
+		t.Fatalf("got %d pipelines to start after the last write; want 1", len(pipelines))
+	}
+	if len(p.branches) != 0 || len(p.writes) != 0 {
+		t.Fatalf("got %d deferred pipelines and %d pending writes; want none", len(p.branches), len(p.writes))
+	}
+}
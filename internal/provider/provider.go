@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 func init() {
@@ -66,11 +68,43 @@ func New(version string) func() *schema.Provider {
 					Default:     "",
 					Description: "File path to client key when GitLab instance is behind company proxy. File must contain PEM encoded data.",
 				},
+				"retries": {
+					Type:         schema.TypeInt,
+					Optional:     true,
+					Default:      3,
+					ValidateFunc: validation.IntAtLeast(0),
+					Description:  "The number of times a change to a repository is retried if it failed because the branch has been changed concurrently or because of a transient API error. Other requests to the GitLab API are not retried.",
+				},
+				"max_retry_wait": {
+					Type:         schema.TypeInt,
+					Optional:     true,
+					Default:      30,
+					ValidateFunc: validation.IntAtLeast(1),
+					Description:  "The maximum number of seconds to wait before retrying a change to a repository. A `Retry-After` header sent by GitLab is honored up to this limit.",
+				},
+				"commit_message_templates": commitMessageTemplatesSchema("Default templates of the commit messages by action for all resources."),
+				"skip_ci": {
+					Type:        schema.TypeBool,
+					Optional:    true,
+					Default:     false,
+					Description: "If the commits of all resources should be marked with `[skip ci]`, so that GitLab doesn't start pipelines for them. Resources can override it with their own `skip_ci` attribute.",
+				},
+				"skip_ci_except_last": {
+					Type:        schema.TypeBool,
+					Optional:    true,
+					Default:     false,
+					Description: "If only a single pipeline should run for each branch changed in a run, for its last commit. Terraform doesn't tell the provider which commit is the last one, therefore all commits are marked with `[skip ci]` and a pipeline is started for the head of each changed branch as soon as no other resource is waiting to write to it, which is usually after the last commit of the run. These pipelines are created through the API, so their `CI_PIPELINE_SOURCE` is `api` instead of `push`. A failure to create them is reported as a warning. It takes precedence over `skip_ci`.",
+				},
 			},
 
 			ResourcesMap: map[string]*schema.Resource{
-				"gitlab-repository-files_gitlab_repository_file":      resourceGitlabRepositoryFile(),
-				"gitlab-repository-files_gitlab_project_access_token": resourceGitlabProjectAccessToken(),
+				"gitlab-repository-files_gitlab_repository_file":       resourceGitlabRepositoryFile(),
+				"gitlab-repository-files_gitlab_repository_file_value": resourceGitlabRepositoryFileValue(),
+				"gitlab-repository-files_gitlab_repository_file_lines": resourceGitlabRepositoryFileLines(),
+				"gitlab-repository-files_gitlab_repository_patch":      resourceGitlabRepositoryPatch(),
+				"gitlab-repository-files_gitlab_repository_files":      resourceGitlabRepositoryFiles(),
+				"gitlab-repository-files_gitlab_repository_branch":     resourceGitlabRepositoryBranch(),
+				"gitlab-repository-files_gitlab_project_access_token":  resourceGitlabProjectAccessToken(),
 			},
 		}
 
@@ -89,6 +123,14 @@ func configure(version string, p *schema.Provider) func(context.Context, *schema
 			Insecure:   d.Get("insecure").(bool),
 			ClientCert: d.Get("client_cert").(string),
 			ClientKey:  d.Get("client_key").(string),
+
+			Retries:      d.Get("retries").(int),
+			MaxRetryWait: time.Duration(d.Get("max_retry_wait").(int)) * time.Second,
+
+			CommitMessageTemplates: expandCommitMessageTemplates(d.Get("commit_message_templates")),
+
+			SkipCI:           d.Get("skip_ci").(bool),
+			SkipCIExceptLast: d.Get("skip_ci_except_last").(bool),
 		}
 
 		client, err := config.Client()
@@ -96,10 +138,15 @@ func configure(version string, p *schema.Provider) func(context.Context, *schema
 			return nil, diag.FromErr(err)
 		}
 
+		httpClient, err := config.HTTPClient()
+		if err != nil {
+			return nil, diag.FromErr(err)
+		}
+
 		userAgent := p.UserAgent("terraform-provider-gitlab-repository-files", version)
 		client.UserAgent = userAgent
 
-		return client, diag.FromErr(err)
+		return &Meta{Client: client, Config: &config, HTTPClient: httpClient, Pipelines: repositoryPipelines}, diag.FromErr(err)
 	}
 }
 
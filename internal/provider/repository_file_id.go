@@ -0,0 +1,135 @@
+package provider
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	gitlab "github.com/xanzy/go-gitlab"
+)
+
+// repositoryFileIDVersion prefixes the ID of a repository file, so that the format can be changed later on.
+const repositoryFileIDVersion = "v1"
+
+var repositoryFileIDEscaper = strings.NewReplacer("%", "%25", ":", "%3A")
+
+// buildRepositoryFileID returns the ID of a repository file in the format `v1:{project}:{branch}:{file_path}`.
+// Colons and percent signs in the parts are percent-encoded, so that the ID can always be split unambiguously.
+func buildRepositoryFileID(project, branch, filePath string) string {
+	return strings.Join([]string{
+		repositoryFileIDVersion,
+		repositoryFileIDEscaper.Replace(project),
+		repositoryFileIDEscaper.Replace(branch),
+		repositoryFileIDEscaper.Replace(filePath),
+	}, ":")
+}
+
+// buildRepositoryFileValueID returns the ID of a value of a structured repository file,
+// which is the ID of the file followed by the escaped document path.
+func buildRepositoryFileValueID(project, branch, filePath, documentPath string) string {
+	return buildRepositoryFileID(project, branch, filePath) + ":" + repositoryFileIDEscaper.Replace(documentPath)
+}
+
+// buildRepositoryPatchID returns the ID of a repository patch, which contains a hash of the initial patch,
+// because several patches may change the same files.
+func buildRepositoryPatchID(project, branch, patch string) string {
+	return strings.Join([]string{
+		repositoryFileIDVersion,
+		repositoryFileIDEscaper.Replace(project),
+		repositoryFileIDEscaper.Replace(branch),
+		fmt.Sprintf("%x", sha256.Sum256([]byte(patch)))[:16],
+	}, ":")
+}
+
+// parseRepositoryFileID returns the project, branch and file path of an ID built with buildRepositoryFileID.
+func parseRepositoryFileID(id string) (string, string, string, error) {
+	parts := strings.Split(id, ":")
+	if len(parts) != 4 || parts[0] != repositoryFileIDVersion {
+		return "", "", "", fmt.Errorf("unexpected ID format (%q), expected %s:{project}:{branch}:{file_path}", id, repositoryFileIDVersion)
+	}
+
+	unescapedParts := make([]string, 3)
+	for i, part := range parts[1:] {
+		unescapedPart, err := url.PathUnescape(part)
+		if err != nil {
+			return "", "", "", fmt.Errorf("unexpected ID format (%q): %v", id, err)
+		}
+		unescapedParts[i] = unescapedPart
+	}
+	return unescapedParts[0], unescapedParts[1], unescapedParts[2], nil
+}
+
+// parseRepositoryFileImportID returns the project, branch and file path of a repository file to import.
+// Besides an ID, it accepts `{project}:{branch}:{file_path}`, where the project is its ID or its full path,
+// and GitLab blob URLs like `https://gitlab.com/group/project/-/blob/main/dir/file`.
+func parseRepositoryFileImportID(client *gitlab.Client, id string) (string, string, string, error) {
+	switch {
+	case strings.HasPrefix(id, repositoryFileIDVersion+":"):
+		return parseRepositoryFileID(id)
+	case strings.HasPrefix(id, "https://"), strings.HasPrefix(id, "http://"):
+		return parseRepositoryFileBlobURL(client, id)
+	}
+
+	// git doesn't allow colons in branch names, therefore only the file path may contain further colons.
+	parts := strings.SplitN(id, ":", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", "", "", fmt.Errorf("invalid Repository File import format (%q); expected '{project}:{branch}:{file_path}' or a blob URL", id)
+	}
+	return parts[0], parts[1], parts[2], nil
+}
+
+// resolveRepositoryProjectID returns the ID of the project, which is given by its ID or its full path.
+func resolveRepositoryProjectID(client *gitlab.Client, project string) (string, error) {
+	if _, err := strconv.Atoi(project); err == nil {
+		return project, nil
+	}
+
+	p, _, err := client.Projects.GetProject(project, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get project %s: %v", project, err)
+	}
+	return strconv.Itoa(p.ID), nil
+}
+
+// parseRepositoryFileBlobURL returns the project, branch and file path of a GitLab blob URL.
+// Both, the branch and the file path may contain slashes, therefore the longest prefix
+// of the path after `/-/blob/` which is an existing branch is used as branch.
+func parseRepositoryFileBlobURL(client *gitlab.Client, blobURL string) (string, string, string, error) {
+	relativeURLRoot := strings.TrimSuffix(strings.TrimSuffix(client.BaseURL().Path, "/"), "/api/v4")
+	project, refAndPath, err := splitRepositoryFileBlobURL(blobURL, relativeURLRoot)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	segments := strings.Split(refAndPath, "/")
+	for i := len(segments) - 1; i > 0; i-- {
+		branch := strings.Join(segments[:i], "/")
+		exists, err := repositoryBranchExists(client, project, branch)
+		if err != nil {
+			return "", "", "", err
+		}
+		if exists {
+			return project, branch, strings.Join(segments[i:], "/"), nil
+		}
+	}
+	return "", "", "", fmt.Errorf("blob URL %q doesn't reference an existing branch of project %s", blobURL, project)
+}
+
+// splitRepositoryFileBlobURL returns the project path and the remainder of the blob URL path,
+// which consists of the branch and the file path.
+// The relative URL root of the GitLab instance, if any, is not part of the project path.
+func splitRepositoryFileBlobURL(blobURL, relativeURLRoot string) (string, string, error) {
+	u, err := url.Parse(blobURL)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid blob URL %q: %v", blobURL, err)
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(u.Path, relativeURLRoot), "/-/blob/", 2)
+	project := strings.Trim(parts[0], "/")
+	if len(parts) != 2 || project == "" || !strings.Contains(parts[1], "/") {
+		return "", "", fmt.Errorf("invalid blob URL %q; expected https://{host}/{project}/-/blob/{branch}/{file_path}", blobURL)
+	}
+	return project, strings.TrimSuffix(parts[1], "/"), nil
+}
@@ -0,0 +1,136 @@
+package provider
+
+import (
+	"testing"
+)
+
+func TestRepositoryFileID(t *testing.T) {
+	cases := []struct {
+		givenProject  string
+		givenBranch   string
+		givenFilePath string
+		expectedID    string
+	}{
+		{
+			givenProject:  "42",
+			givenBranch:   "main",
+			givenFilePath: "meow.txt",
+			expectedID:    "v1:42:main:meow.txt",
+		},
+		{
+			givenProject:  "group/project",
+			givenBranch:   "release/1.0",
+			givenFilePath: "launch:codes/100%.txt",
+			expectedID:    "v1:group/project:release/1.0:launch%3Acodes/100%25.txt",
+		},
+	}
+
+	for _, c := range cases {
+		id := buildRepositoryFileID(c.givenProject, c.givenBranch, c.givenFilePath)
+		if id != c.expectedID {
+			t.Fatalf("got ID %q; want %q", id, c.expectedID)
+		}
+
+		project, branch, filePath, err := parseRepositoryFileID(id)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if project != c.givenProject || branch != c.givenBranch || filePath != c.givenFilePath {
+			t.Fatalf("got %q, %q, %q from ID %q", project, branch, filePath, id)
+		}
+	}
+
+	for _, id := range []string{"meow.txt", "42:main:meow.txt", "v2:42:main:meow.txt"} {
+		if _, _, _, err := parseRepositoryFileID(id); err == nil {
+			t.Fatalf("expected an error for ID %q", id)
+		}
+	}
+}
+
+func TestParseRepositoryFileImportID(t *testing.T) {
+	cases := []struct {
+		givenID          string
+		expectedProject  string
+		expectedBranch   string
+		expectedFilePath string
+	}{
+		{
+			givenID:          "42:main:meow.txt",
+			expectedProject:  "42",
+			expectedBranch:   "main",
+			expectedFilePath: "meow.txt",
+		},
+		{
+			givenID:          "group/project:main:launch:codes.txt",
+			expectedProject:  "group/project",
+			expectedBranch:   "main",
+			expectedFilePath: "launch:codes.txt",
+		},
+		{
+			givenID:          "v1:42:main:launch%3Acodes.txt",
+			expectedProject:  "42",
+			expectedBranch:   "main",
+			expectedFilePath: "launch:codes.txt",
+		},
+	}
+
+	for _, c := range cases {
+		project, branch, filePath, err := parseRepositoryFileImportID(nil, c.givenID)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", c.givenID, err)
+		}
+		if project != c.expectedProject || branch != c.expectedBranch || filePath != c.expectedFilePath {
+			t.Fatalf("got %q, %q, %q from import ID %q", project, branch, filePath, c.givenID)
+		}
+	}
+
+	for _, id := range []string{"meow.txt", "42:main", "42::meow.txt"} {
+		if _, _, _, err := parseRepositoryFileImportID(nil, id); err == nil {
+			t.Fatalf("expected an error for import ID %q", id)
+		}
+	}
+}
+
+func TestResolveRepositoryProjectID(t *testing.T) {
+	// a project given by its ID is used as it is, without asking GitLab.
+	if project, err := resolveRepositoryProjectID(nil, "42"); err != nil || project != "42" {
+		t.Fatalf("got project %q (error: %v); want %q", project, err, "42")
+	}
+}
+
+func TestSplitRepositoryFileBlobURL(t *testing.T) {
+	cases := []struct {
+		givenURL             string
+		givenRelativeURLRoot string
+		expectedProject      string
+		expectedRefAndPath   string
+	}{
+		{
+			givenURL:           "https://gitlab.com/group/project/-/blob/main/dir/file",
+			expectedProject:    "group/project",
+			expectedRefAndPath: "main/dir/file",
+		},
+		{
+			givenURL:             "https://example.com/gitlab/group/sub/project/-/blob/release/1.0/launch%20codes.txt",
+			givenRelativeURLRoot: "/gitlab",
+			expectedProject:      "group/sub/project",
+			expectedRefAndPath:   "release/1.0/launch codes.txt",
+		},
+	}
+
+	for _, c := range cases {
+		project, refAndPath, err := splitRepositoryFileBlobURL(c.givenURL, c.givenRelativeURLRoot)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", c.givenURL, err)
+		}
+		if project != c.expectedProject || refAndPath != c.expectedRefAndPath {
+			t.Fatalf("got %q, %q from blob URL %q", project, refAndPath, c.givenURL)
+		}
+	}
+
+	for _, blobURL := range []string{"https://gitlab.com/group/project", "https://gitlab.com/-/blob/main/file", "https://gitlab.com/group/project/-/blob/main"} {
+		if _, _, err := splitRepositoryFileBlobURL(blobURL, ""); err == nil {
+			t.Fatalf("expected an error for blob URL %q", blobURL)
+		}
+	}
+}
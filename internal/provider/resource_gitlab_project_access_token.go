@@ -79,7 +79,7 @@ func resourceGitlabProjectAccessToken() *schema.Resource {
 }
 
 func resourceGitlabProjectAccessTokenCreate(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(*gitlab.Client)
+	client := meta.(*Meta).Client
 	project := d.Get("project").(int)
 	options := &gitlab.CreateProjectAccessTokenOptions{
 		Name:   gitlab.String(d.Get("name").(string)),
@@ -120,7 +120,7 @@ func resourceGitlabProjectAccessTokenRead(d *schema.ResourceData, meta interface
 		return fmt.Errorf("Error parsing ID: %s", d.Id())
 	}
 
-	client := meta.(*gitlab.Client)
+	client := meta.(*Meta).Client
 
 	project, err := strconv.Atoi(projectString)
 	if err != nil {
@@ -182,7 +182,7 @@ func resourceGitlabProjectAccessTokenDelete(d *schema.ResourceData, meta interfa
 		return fmt.Errorf("Error parsing ID: %s", d.Id())
 	}
 
-	client := meta.(*gitlab.Client)
+	client := meta.(*Meta).Client
 
 	project, err := strconv.Atoi(projectString)
 	if err != nil {
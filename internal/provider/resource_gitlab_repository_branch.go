@@ -0,0 +1,164 @@
+package provider
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	gitlab "github.com/xanzy/go-gitlab"
+)
+
+func resourceGitlabRepositoryBranch() *schema.Resource {
+	return &schema.Resource{
+		// This description is used by the documentation generator and the language server.
+		Description: `This resource allows you to manage the lifecycle of a branch to which repository files are committed
+
+The branch is created from the ` + "`ref`" + ` if it doesn't exist yet, otherwise the existing branch is used.
+On destroy, the branch is only deleted if it has been created by this resource
+and if it doesn't contain any changes compared to the ` + "`ref`" + ` anymore,
+i.e. all files committed to it have been destroyed before.
+Reference the branch from the repository file resources, so that they are destroyed first.
+
+` + "```" + `hcl
+resource "gitlab-repository-files_gitlab_repository_branch" "this" {
+	project = gitlab_project.foo.id
+	branch  = "feature/launch-codes"
+	ref     = "main"
+}
+
+resource "gitlab-repository-files_gitlab_repository_file" "this" {
+	project        = gitlab-repository-files_gitlab_repository_branch.this.project
+	branch         = gitlab-repository-files_gitlab_repository_branch.this.branch
+	file_path      = "meow.txt"
+	content        = "hello world"
+	author_email   = "meow@catnip.com"
+	author_name    = "Meow Meowington"
+	commit_message = "feature: add launch codes"
+}
+` + "```",
+
+		CreateContext: resourceGitlabRepositoryBranchCreate,
+		ReadContext:   resourceGitlabRepositoryBranchRead,
+		DeleteContext: resourceGitlabRepositoryBranchDelete,
+
+		Schema: map[string]*schema.Schema{
+			"project": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The ID or full path of the project.",
+			},
+			"branch": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The name of the branch.",
+			},
+			"ref": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The branch name or commit SHA to create the branch from.",
+			},
+			"created": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "If the branch has been created by this resource. Only then it's deleted on destroy.",
+			},
+		},
+	}
+}
+
+func resourceGitlabRepositoryBranchCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*Meta).Client
+	project := d.Get("project").(string)
+	branch := d.Get("branch").(string)
+
+	lockKey := repositoryBranchLockKey(project, branch)
+	repositoryBranchMutexKV.Lock(lockKey)
+	defer repositoryBranchMutexKV.Unlock(lockKey)
+
+	exists, err := repositoryBranchExists(client, project, branch)
+	if err != nil {
+		return diag.Errorf("failed to get branch %s: %v", branch, err)
+	}
+
+	if exists {
+		log.Printf("[DEBUG] using existing branch %s of project %s", branch, project)
+	} else {
+		_, _, err := client.Branches.CreateBranch(project, &gitlab.CreateBranchOptions{
+			Branch: gitlab.String(branch),
+			Ref:    gitlab.String(d.Get("ref").(string)),
+		})
+		if err != nil {
+			return diag.Errorf("failed to create branch %s from %s: %v", branch, d.Get("ref").(string), err)
+		}
+		log.Printf("[DEBUG] created branch %s of project %s from %s", branch, project, d.Get("ref").(string))
+	}
+	d.Set("created", !exists)
+
+	d.SetId(buildTwoPartID(&project, &branch))
+	return resourceGitlabRepositoryBranchRead(ctx, d, meta)
+}
+
+func resourceGitlabRepositoryBranchRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*Meta).Client
+	project, branch, err := parseTwoPartID(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	exists, err := repositoryBranchExists(client, project, branch)
+	if err != nil {
+		return diag.Errorf("failed to get branch %s: %v", branch, err)
+	}
+	if !exists {
+		log.Printf("[WARN] branch %s of project %s not found, removing from state", branch, project)
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("project", project)
+	d.Set("branch", branch)
+	return nil
+}
+
+func resourceGitlabRepositoryBranchDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*Meta).Client
+	project := d.Get("project").(string)
+	branch := d.Get("branch").(string)
+	ref := d.Get("ref").(string)
+
+	if !d.Get("created").(bool) {
+		log.Printf("[DEBUG] keeping branch %s of project %s, because it hasn't been created by this resource", branch, project)
+		return nil
+	}
+
+	lockKey := repositoryBranchLockKey(project, branch)
+	repositoryBranchMutexKV.Lock(lockKey)
+	defer repositoryBranchMutexKV.Unlock(lockKey)
+
+	compare, resp, err := client.Repositories.Compare(project, &gitlab.CompareOptions{
+		From: gitlab.String(ref),
+		To:   gitlab.String(branch),
+	})
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			log.Printf("[WARN] keeping branch %s of project %s, because it can't be compared with %s anymore", branch, project, ref)
+			return nil
+		}
+		return diag.Errorf("failed to compare branch %s with %s: %v", branch, ref, err)
+	}
+	if len(compare.Diffs) > 0 {
+		log.Printf("[WARN] keeping branch %s of project %s, because it still contains %d changed files compared to %s", branch, project, len(compare.Diffs), ref)
+		return nil
+	}
+
+	resp, err = client.Branches.DeleteBranch(project, branch)
+	if err != nil && (resp == nil || resp.StatusCode != http.StatusNotFound) {
+		return diag.Errorf("failed to delete branch %s: %v", branch, err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestAccGitlabRepositoryBranch_createWithFiles(t *testing.T) {
+	rInt := acctest.RandInt()
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		CheckDestroy:      testAccCheckGitlabRepositoryBranchDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccGitlabRepositoryBranchConfig(rInt),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("gitlab-repository-files_gitlab_repository_branch.this", "created", "true"),
+					resource.TestCheckResourceAttr("gitlab-repository-files_gitlab_repository_file.meow", "branch", "feature/launch-codes"),
+					resource.TestCheckResourceAttr("gitlab-repository-files_gitlab_repository_file.codes", "branch", "feature/launch-codes"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckGitlabRepositoryBranchDestroy(s *terraform.State) error {
+	testAccProvider, _ := providerFactories["gitlab-repository-files"]()
+	conn := testAccProvider.Meta().(*Meta).Client
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "gitlab-repository-files_gitlab_repository_branch" {
+			continue
+		}
+
+		exists, err := repositoryBranchExists(conn, rs.Primary.Attributes["project"], rs.Primary.Attributes["branch"])
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("Branch %s still exists", rs.Primary.Attributes["branch"])
+		}
+	}
+	return nil
+}
+
+func testAccGitlabRepositoryBranchConfig(rInt int) string {
+	return fmt.Sprintf(`
+resource "gitlab_project" "foo" {
+  name = "foo-%d"
+  description = "Terraform acceptance tests"
+
+  default_branch = "main"
+
+  # So that acceptance tests can be run in a gitlab organization
+  # with no billing
+  visibility_level = "public"
+  initialize_with_readme = true
+}
+
+resource "gitlab-repository-files_gitlab_repository_branch" "this" {
+  project = "${gitlab_project.foo.id}"
+  branch = "feature/launch-codes"
+  ref = "main"
+}
+
+resource "gitlab-repository-files_gitlab_repository_file" "meow" {
+  project = gitlab-repository-files_gitlab_repository_branch.this.project
+  branch = gitlab-repository-files_gitlab_repository_branch.this.branch
+  file_path = "meow.txt"
+  content = "meow"
+  author_email = "meow@catnip.com"
+  author_name = "Meow Meowington"
+  commit_message = "feature: add meow"
+}
+
+resource "gitlab-repository-files_gitlab_repository_file" "codes" {
+  project = gitlab-repository-files_gitlab_repository_branch.this.project
+  branch = gitlab-repository-files_gitlab_repository_branch.this.branch
+  file_path = "launch/codes.txt"
+  content = "1234"
+  author_email = "meow@catnip.com"
+  author_name = "Meow Meowington"
+  commit_message = "feature: add launch codes"
+}
+	`, rInt)
+}
@@ -2,19 +2,55 @@ package provider
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
 	"fmt"
 	"log"
+	"net/http"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 
 	gitlab "github.com/xanzy/go-gitlab"
 )
 
 const encoding = "base64"
 
+// executableFilemode is the git filemode of an executable file.
+const executableFilemode = "100755"
+
+const (
+	// ifExistsFail fails to create a file which does already exist.
+	ifExistsFail = "fail"
+	// ifExistsOverwrite overwrites a file which does already exist.
+	ifExistsOverwrite = "overwrite"
+	// ifExistsAdoptIfIdentical takes over a file which does already exist with the configured content.
+	ifExistsAdoptIfIdentical = "adopt_if_identical"
+)
+
+const (
+	// normalizeNone commits the content as it is.
+	normalizeNone = "none"
+	// normalizeLF converts all line endings of the content to LF.
+	normalizeLF = "lf"
+	// normalizeCRLF converts all line endings of the content to CRLF.
+	normalizeCRLF = "crlf"
+	// normalizeTrimTrailingNewline removes all trailing newlines from the content.
+	normalizeTrimTrailingNewline = "trim_trailing_newline"
+)
+
+const (
+	// onDestroyDelete deletes the file on destroy.
+	onDestroyDelete = "delete"
+	// onDestroyRestore restores the content of a file which has been taken over on destroy.
+	onDestroyRestore = "restore"
+	// onDestroyAbandon leaves the file as it is on destroy.
+	onDestroyAbandon = "abandon"
+)
+
 func resourceGitlabRepositoryFile() *schema.Resource {
 	return &schema.Resource{
 		// This description is used by the documentation generator and the language server.
@@ -27,9 +63,10 @@ can only create, update or delete a single file at the time.
 The API will also
 [fail with a 400](https://docs.gitlab.com/ee/api/repository_files.html#update-existing-file-in-repository)
 response status code if the underlying repository is changed while the API tries to make changes.
-Therefore, it's recommended to make sure that you execute it with
-[-parallelism=1](https://www.terraform.io/docs/cli/commands/apply.html#parallelism-n)
-and that no other entity than the terraform at hand makes changes to the
+Therefore, the provider serializes all changes to the same branch of a project,
+while changes to other branches are still made in parallel.
+For this to work, a project must be referenced consistently, either by its ID or by its path.
+It's recommended to make sure that no other entity than the terraform at hand makes changes to the
 underlying repository while it's executing.
 
 ` + "```" + `hcl
@@ -37,163 +74,422 @@ resource "gitlab-repository-files_gitlab_repository_file" "this" {
 	project        = gitlab_project.foo.id
 	file_path      = "meow.txt"
 	branch         = "main"
-	content        = base64encode("hello world")
+	content        = "hello world"
 	author_email   = "meow@catnip.com"
 	author_name    = "Meow Meowington"
 	commit_message = "feature: add launch codes"
 }
 
-` + "```",
+` + "```" + `
+
+**Import**:
+
+A repository file can be imported with ` + "`{project}:{branch}:{file_path}`" + `, where the project is its ID or its full path,
+or with the URL of the file in GitLab, e.g. ` + "`https://gitlab.com/group/project/-/blob/main/meow.txt`" + `.
+The project is stored by its ID regardless of the form it's imported with, so the configuration must reference it by its ID.`,
 
 		CreateContext: resourceGitlabRepositoryFileCreate,
 		ReadContext:   resourceGitlabRepositoryFileRead,
 		UpdateContext: resourceGitlabRepositoryFileUpdate,
 		DeleteContext: resourceGitlabRepositoryFileDelete,
+		CustomizeDiff: resourceGitlabRepositoryFileCustomizeDiff,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(60 * time.Minute),
+			Update: schema.DefaultTimeout(60 * time.Minute),
+			Delete: schema.DefaultTimeout(60 * time.Minute),
+		},
+		SchemaVersion: 2,
+		StateUpgraders: []schema.StateUpgrader{
+			{
+				Type:    resourceGitlabRepositoryFileResourceV0().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourceGitlabRepositoryFileStateUpgradeV0,
+				Version: 0,
+			},
+			{
+				Type:    resourceGitlabRepositoryFileResourceV1().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourceGitlabRepositoryFileStateUpgradeV1,
+				Version: 1,
+			},
+		},
 		Importer: &schema.ResourceImporter{
-			State: func(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				s := strings.Split(d.Id(), ":")
-
-				if len(s) != 3 {
-					d.SetId("")
-					return nil, fmt.Errorf("invalid Repository File import format; expected '{project_id}:{branch}:{file_path}'")
-				}
-				project, branch, filePath := s[0], s[1], s[2]
+			State: resourceGitlabRepositoryFileImport,
+		},
 
-				d.SetId(filePath)
-				d.Set("project", project)
-				d.Set("branch", branch)
+		Schema: resourceGitlabRepositoryFileSchema(),
+	}
+}
 
-				return []*schema.ResourceData{d}, nil
-			},
+// resourceGitlabRepositoryFileSchema returns the schema of the repository file resource.
+// The schema matches https://docs.gitlab.com/ee/api/repository_files.html#create-new-file-in-repository
+// However, we don't support the `encoding` parameter as it seems to be broken.
+// Only a value of `base64` is supported, all others, including the documented default `text`, lead to
+// a `400 {error: encoding does not have a valid value}` error.
+func resourceGitlabRepositoryFileSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"project": {
+			Type:        schema.TypeString,
+			Required:    true,
+			ForceNew:    true,
+			Description: "The ID of the project.",
 		},
-
-		// the schema matches https://docs.gitlab.com/ee/api/repository_files.html#create-new-file-in-repository
-		// However, we don't support the `encoding` parameter as it seems to be broken.
-		// Only a value of `base64` is supported, all others, including the documented default `text`, lead to
-		// a `400 {error: encoding does not have a valid value}` error.
-		Schema: map[string]*schema.Schema{
-			"project": {
-				Type:        schema.TypeString,
-				Required:    true,
-				ForceNew:    true,
-				Description: "The ID of the project.",
-			},
-			"file_path": {
-				Type:        schema.TypeString,
-				Required:    true,
-				ForceNew:    true,
-				Description: "The full path of the file. It must be relative to the root of the project without a leading slash `/`.",
-			},
-			"branch": {
-				Type:        schema.TypeString,
-				Required:    true,
-				ForceNew:    true,
-				Description: "The name of the branch to which to commit to.",
-			},
-			"start_branch": {
-				Type:        schema.TypeString,
-				Optional:    true,
-				Description: "Name of the branch to start the new commit from.",
-			},
-			"author_email": {
-				Type:        schema.TypeString,
-				Optional:    true,
-				Description: "The email address of the commit author.",
-			},
-			"author_name": {
-				Type:        schema.TypeString,
-				Optional:    true,
-				Description: "The name of the commit author.",
-			},
-			"content": {
-				Type:         schema.TypeString,
-				Required:     true,
-				ValidateFunc: validateBase64Content,
-				Description:  "The content of the file. It must be base64 encoded.",
-			},
-			"commit_message": {
-				Type:        schema.TypeString,
-				Required:    true,
-				Description: "The commit message.",
-			},
-			"overwrite_on_create": {
-				Type:        schema.TypeBool,
-				Optional:    true,
-				Description: "If the file should be overwritten if it does already exist in the repository but not in the state.",
-			},
+		"file_path": {
+			Type:        schema.TypeString,
+			Required:    true,
+			Description: "The full path of the file. It must be relative to the root of the project without a leading slash `/`. Changing it moves the file in place, so that its history is kept.",
+		},
+		"branch": {
+			Type:        schema.TypeString,
+			Required:    true,
+			ForceNew:    true,
+			Description: "The name of the branch to which to commit to.",
+		},
+		"start_branch": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "Name of the branch to create the `branch` from, if it doesn't exist yet. Use the `gitlab_repository_branch` resource to also delete a created branch on destroy.",
+		},
+		"author_email": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "The email address of the commit author.",
+		},
+		"author_name": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "The name of the commit author.",
+		},
+		"content": {
+			Type:             schema.TypeString,
+			Optional:         true,
+			ExactlyOneOf:     []string{"content", "content_base64"},
+			DiffSuppressFunc: suppressRepositoryFileContentDiff,
+			Description:      "The content of the file as UTF-8 text. Conflicts with `content_base64`.",
+		},
+		"content_base64": {
+			Type:             schema.TypeString,
+			Optional:         true,
+			ExactlyOneOf:     []string{"content", "content_base64"},
+			ValidateFunc:     validateBase64Content,
+			DiffSuppressFunc: suppressRepositoryFileContentHashOnlyDiff,
+			Description:      "The content of the file. It must be base64 encoded. Use it for binary files. Conflicts with `content`.",
+		},
+		"normalize": {
+			Type:          schema.TypeString,
+			Optional:      true,
+			Default:       normalizeNone,
+			ValidateFunc:  validation.StringInSlice([]string{normalizeNone, normalizeLF, normalizeCRLF, normalizeTrimTrailingNewline}, false),
+			ConflictsWith: []string{"content_base64", "managed_block"},
+			Description:   "How the `content` is normalized before it's committed. Either `none` to commit it as it is, `lf` or `crlf` to convert all line endings or `trim_trailing_newline` to remove all trailing newlines. The content of the file in the repository and in the configuration is also compared after normalizing it, so that contents which only differ in their line endings don't cause a diff.",
+		},
+		"store_content_hash_only": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "If only the `content_sha256` of the file should be stored in the state instead of its content. Changes to the file are detected by comparing it with the SHA256 reported by GitLab. Use it for large or sensitive files.",
+		},
+		"content_sha256": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The SHA256 hex digest of the content of the file.",
+		},
+		"lfs": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Computed:    true,
+			Description: "If the content of the file should be uploaded to the Git LFS storage of the project and only its pointer file committed. Defaults to whether the `file_path` is tracked by LFS in the `.gitattributes` of the `branch` when the file is created. If the branch the file is committed to doesn't exist yet, the `.gitattributes` of the branch it's created from are used, i.e. the `start_branch` or, for the merge request delivery, the `branch`. The `content_sha256` of an LFS file is compared with the object id of its pointer file.",
+		},
+		"executable": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "If the file should have the execute filemode set, e.g. for scripts or git hooks.",
+		},
+		"commit_message": {
+			Type:        schema.TypeString,
+			Required:    true,
+			Description: "The commit message.",
+		},
+		"commit_message_templates": commitMessageTemplatesSchema("Templates of the commit messages by action. They take precedence over the templates of the provider."),
+		"trailers":                 commitTrailersSchema(),
+		"co_authors":               commitCoAuthorsSchema(),
+		"skip_ci":                  commitSkipCISchema(),
+		"overwrite_on_create": {
+			Type:          schema.TypeBool,
+			Optional:      true,
+			Deprecated:    "Use `if_exists = \"overwrite\"` instead.",
+			ConflictsWith: []string{"if_exists"},
+			Description:   "If the file should be overwritten if it does already exist in the repository but not in the state.",
+		},
+		"if_exists": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ValidateFunc: validation.StringInSlice([]string{ifExistsFail, ifExistsOverwrite, ifExistsAdoptIfIdentical}, false),
+			Description:  "What happens if the file does already exist in the repository but not in the state. Either `fail` to fail, `overwrite` to overwrite it or `adopt_if_identical` to take it over without a commit if it already has the configured content. Defaults to `fail`.",
+		},
+		"force_commit": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "If every update should create a commit, even if the content and the filemode of the file on the branch already match the configuration. By default, a change of e.g. only the `commit_message` is just stored in the state.",
+		},
+		"managed_block": managedBlockSchema(),
+		"on_destroy": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Default:      onDestroyDelete,
+			ValidateFunc: validation.StringInSlice([]string{onDestroyDelete, onDestroyRestore, onDestroyAbandon}, false),
+			Description:  "What happens to the file on destroy. Either `delete` to delete it, `restore` to restore the content it had before it was taken over with `if_exists` or `abandon` to leave it as it is. A file which didn't exist before is deleted if it should be restored.",
+		},
+		"original_blob_id": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The ID of the blob which has been replaced when an existing file was taken over with `if_exists`.",
+		},
+		"overwrite_concurrent_changes": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "If the file should be updated or deleted even if it has been changed in the repository since it was last read. By default, such a change leads to a conflict error.",
+		},
+		"delivery": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Default:      deliveryCommit,
+			ValidateFunc: validation.StringInSlice([]string{deliveryCommit, deliveryMergeRequest}, false),
+			Description:  "How changes to the file are delivered to the branch. Either `commit` to commit them directly to the branch or `merge_request` to commit them to the `merge_request_source_branch` and open a merge request against the branch.",
+		},
+		"merge_request_source_branch": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Computed:    true,
+			Description: "The source branch of the merge request if `delivery` is `merge_request`. It's created from `start_branch` or `branch` if it doesn't exist. Defaults to a branch name generated from `branch` and `file_path`.",
+		},
+		"merge_request_title": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "The title of the merge request if `delivery` is `merge_request`. Defaults to the first line of the `commit_message`.",
+		},
+		"merge_request_auto_merge": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "If the merge request should be set to merge when its pipeline succeeds if `delivery` is `merge_request`.",
+		},
+		"merge_request_wait_for_merge": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "If the apply should wait until the merge request is merged if `delivery` is `merge_request`. The apply fails if the merge request is closed, its pipeline fails or the timeout is exceeded.",
+		},
+		"merge_request_iid": {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "The IID of the merge request opened for the last change if `delivery` is `merge_request`.",
+		},
+		"merge_request_web_url": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The web URL of the merge request opened for the last change if `delivery` is `merge_request`.",
+		},
+		"merge_request_merge_commit_sha": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The SHA of the commit which merged the merge request into the branch, once it has been merged.",
+		},
+		"last_commit_id": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The ID of the last commit which changed the file.",
+		},
+		"blob_id": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The ID of the blob of the file.",
 		},
 	}
 }
 
 func resourceGitlabRepositoryFileCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*gitlab.Client)
+	client := meta.(*Meta).Client
 	project := d.Get("project").(string)
 	filePath := d.Get("file_path").(string)
 
-	var existingRepositoryFile *gitlab.File
-	if d.Get("overwrite_on_create").(bool) {
-		readOptions := &gitlab.GetFileOptions{
-			Ref: gitlab.String(d.Get("branch").(string)),
-		}
+	useLFS, err := repositoryFileUseLFS(client, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.Set("lfs", useLFS)
 
-		existingRepositoryFile, _, _ = client.RepositoryFiles.GetFile(project, filePath, readOptions)
+	content, err := repositoryFileCommitContent(ctx, meta.(*Meta), d)
+	if err != nil {
+		return diag.FromErr(err)
 	}
 
-	var filePathForId string
-	if existingRepositoryFile == nil {
-		options := &gitlab.CreateFileOptions{
-			Branch:        gitlab.String(d.Get("branch").(string)),
-			Encoding:      gitlab.String(encoding),
-			AuthorEmail:   gitlab.String(d.Get("author_email").(string)),
-			AuthorName:    gitlab.String(d.Get("author_name").(string)),
-			Content:       gitlab.String(d.Get("content").(string)),
-			CommitMessage: gitlab.String(d.Get("commit_message").(string)),
-		}
-		if startBranch, ok := d.GetOk("start_branch"); ok {
-			options.StartBranch = gitlab.String(startBranch.(string))
+	ifExists := repositoryFileIfExists(d)
+
+	// a new file only consists of the managed block.
+	block := expandManagedBlock(d.Get("managed_block"))
+	if block != nil {
+		content = base64.StdEncoding.EncodeToString([]byte(block.Render(d.Get("content").(string))))
+	}
+
+	lockKey := repositoryBranchLockKey(project, d.Get("branch").(string))
+	lockRepositoryBranch(meta.(*Meta), lockKey)
+
+	var committed bool
+	var commitBranch string
+	err = retryRepositoryWrite(ctx, meta.(*Meta).Config, func() error {
+		branch, startBranch, err := repositoryFileWriteBranches(client, d)
+		if err != nil {
+			return err
 		}
 
-		repositoryFile, _, err := client.RepositoryFiles.CreateFile(project, filePath, options)
+		readBranch := branch
+		if startBranch != "" {
+			readBranch = startBranch
+		}
+		existingRepositoryFile, err := getExistingRepositoryFile(client, project, filePath, readBranch)
 		if err != nil {
-			return diag.FromErr(err)
+			return err
 		}
-		filePathForId = repositoryFile.FilePath
-	} else {
-		options := &gitlab.UpdateFileOptions{
-			Branch:        gitlab.String(d.Get("branch").(string)),
-			Encoding:      gitlab.String(encoding),
-			AuthorEmail:   gitlab.String(d.Get("author_email").(string)),
-			AuthorName:    gitlab.String(d.Get("author_name").(string)),
-			Content:       gitlab.String(d.Get("content").(string)),
-			CommitMessage: gitlab.String(d.Get("commit_message").(string)),
-			LastCommitID:  gitlab.String(existingRepositoryFile.LastCommitID),
+
+		action := &gitlab.CommitActionOptions{
+			Action:   gitlab.FileAction(gitlab.FileCreate),
+			FilePath: gitlab.String(filePath),
+			Content:  gitlab.String(content),
+			Encoding: gitlab.String(encoding),
 		}
-		if startBranch, ok := d.GetOk("start_branch"); ok {
-			options.StartBranch = gitlab.String(startBranch.(string))
+		actions := []*gitlab.CommitActionOptions{action}
+		if d.Get("executable").(bool) {
+			actions = append(actions, repositoryFileChmodAction(d))
 		}
 
-		repositoryFile, _, err := client.RepositoryFiles.UpdateFile(project, filePath, options)
-		if err != nil {
-			return diag.FromErr(err)
+		if existingRepositoryFile != nil {
+			d.Set("original_blob_id", existingRepositoryFile.BlobID)
+
+			switch {
+			case block != nil:
+				fileContent, err := getRepositoryFileContent(client, project, filePath, readBranch)
+				if err != nil {
+					return err
+				}
+				executable, err := isRepositoryFileExecutable(client, project, filePath, readBranch)
+				if err != nil {
+					return err
+				}
+
+				actions = []*gitlab.CommitActionOptions{}
+				if newFileContent := block.Upsert(fileContent, block, d.Get("content").(string)); newFileContent != fileContent {
+					action.Action = gitlab.FileAction(gitlab.FileUpdate)
+					action.Content = gitlab.String(base64.StdEncoding.EncodeToString([]byte(newFileContent)))
+					action.LastCommitID = gitlab.String(existingRepositoryFile.LastCommitID)
+					actions = append(actions, action)
+				}
+				if executable != d.Get("executable").(bool) {
+					actions = append(actions, repositoryFileChmodAction(d))
+				}
+				if len(actions) == 0 {
+					log.Printf("[DEBUG] managed block of file %s is already up to date, skipping commit", filePath)
+					committed = false
+					return nil
+				}
+			case ifExists == ifExistsFail:
+				return fmt.Errorf("file %s already exists on branch %s, set `if_exists` to %q or %q to take it over", filePath, readBranch, ifExistsOverwrite, ifExistsAdoptIfIdentical)
+			case ifExists == ifExistsAdoptIfIdentical:
+				contentSHA256, err := repositoryFileConfiguredContentSHA256("", content)
+				if err != nil {
+					return err
+				}
+				if contentSHA256 != existingRepositoryFile.SHA256 {
+					return fmt.Errorf("file %s already exists on branch %s with a different content, set `if_exists` to %q to overwrite it", filePath, readBranch, ifExistsOverwrite)
+				}
+
+				executable, err := isRepositoryFileExecutable(client, project, filePath, readBranch)
+				if err != nil {
+					return err
+				}
+				if executable == d.Get("executable").(bool) {
+					log.Printf("[DEBUG] adopting identical file %s without a commit", filePath)
+					committed = false
+					return nil
+				}
+				actions = []*gitlab.CommitActionOptions{repositoryFileChmodAction(d)}
+			default:
+				action.Action = gitlab.FileAction(gitlab.FileUpdate)
+				action.LastCommitID = gitlab.String(existingRepositoryFile.LastCommitID)
+
+				// an overwritten file may have any filemode, therefore it's always set explicitly.
+				if !d.Get("executable").(bool) {
+					actions = append(actions, repositoryFileChmodAction(d))
+				}
+			}
+		}
+
+		committed, commitBranch = true, branch
+		return commitRepositoryFile(client, d, branch, startBranch, commitMessage(meta.(*Meta), d, commitActionCreate, filePath), actions)
+	})
+	if err == nil && committed {
+		deferRepositoryPipeline(meta.(*Meta), d, commitBranch)
+	}
+	// the branch is unlocked before the merge request is delivered, which may wait for its merge.
+	diags := unlockRepositoryBranch(meta.(*Meta), lockKey)
+	if err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
+
+	// the ID is set before the merge request is delivered, so that the committed file is kept
+	// in the state even if the delivery fails.
+	d.SetId(buildRepositoryFileID(project, d.Get("branch").(string), filePath))
+
+	if committed && d.Get("delivery").(string) == deliveryMergeRequest {
+		if err := deliverRepositoryFileMergeRequest(ctx, client, d, d.Timeout(schema.TimeoutCreate)); err != nil {
+			return append(diags, diag.FromErr(err)...)
 		}
-		filePathForId = repositoryFile.FilePath
 	}
 
-	d.SetId(filePathForId)
-	return resourceGitlabRepositoryFileRead(ctx, d, meta)
+	return append(diags, resourceGitlabRepositoryFileRead(ctx, d, meta)...)
+}
+
+func resourceGitlabRepositoryFileImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	project, branch, filePath, err := parseRepositoryFileImportID(meta.(*Meta).Client, d.Id())
+	if err != nil {
+		return nil, err
+	}
+	// the project is stored by its ID, which is how configurations usually reference it,
+	// so that a file imported by the path of its project isn't replaced.
+	project, err = resolveRepositoryProjectID(meta.(*Meta).Client, project)
+	if err != nil {
+		return nil, err
+	}
+
+	d.SetId(buildRepositoryFileID(project, branch, filePath))
+	d.Set("project", project)
+	d.Set("branch", branch)
+	d.Set("file_path", filePath)
+
+	return []*schema.ResourceData{d}, nil
 }
 
 func resourceGitlabRepositoryFileRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*gitlab.Client)
-	project := d.Get("project").(string)
-	filePath := d.Id()
+	client := meta.(*Meta).Client
+	project, _, filePath, err := parseRepositoryFileID(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	readBranch, err := repositoryFileReadBranch(client, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 	options := &gitlab.GetFileOptions{
-		Ref: gitlab.String(d.Get("branch").(string)),
+		Ref: gitlab.String(readBranch),
 	}
 
-	repositoryFile, _, err := client.RepositoryFiles.GetFile(project, filePath, options)
+	// The metadata is fetched with a HEAD request first, so that the content
+	// only has to be downloaded if the file has changed since the last read.
+	repositoryFile, resp, err := client.RepositoryFiles.GetFileMetaData(project, filePath, &gitlab.GetFileMetaDataOptions{Ref: options.Ref})
 	if err != nil {
-		if strings.Contains(err.Error(), "404 File Not Found") {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
 			log.Printf("[WARN] file %s not found, removing from state", filePath)
 			d.SetId("")
 			return nil
@@ -201,80 +497,680 @@ func resourceGitlabRepositoryFileRead(ctx context.Context, d *schema.ResourceDat
 		return diag.FromErr(err)
 	}
 
+	// the content hash of an LFS file is the object id in its pointer file.
+	contentSHA256 := repositoryFile.SHA256
+	if d.Get("lfs").(bool) {
+		contentSHA256, err = repositoryFileLFSObjectID(client, project, filePath, options)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
 	d.Set("project", project)
 	d.Set("file_path", repositoryFile.FilePath)
-	d.Set("branch", repositoryFile.Ref)
-	d.Set("encoding", repositoryFile.Encoding)
-	d.Set("content", repositoryFile.Content)
+	switch block := expandManagedBlock(d.Get("managed_block")); {
+	case block != nil:
+		// only the content of the managed block is compared with the configuration.
+		repositoryFile, _, err = client.RepositoryFiles.GetFile(project, filePath, options)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		fileContent, err := base64.StdEncoding.DecodeString(repositoryFile.Content)
+		if err != nil {
+			return diag.Errorf("failed to decode content of repository file %s: %v", d.Id(), err)
+		}
+
+		blockContent, ok := block.Extract(string(fileContent))
+		if !ok {
+			log.Printf("[DEBUG] file %s doesn't contain the managed block anymore", filePath)
+		}
+		blockContent = managedBlockStateContent(blockContent, d.Get("content").(string))
+		contentSHA256, err = repositoryFileConfiguredContentSHA256(blockContent, "")
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		if d.Get("store_content_hash_only").(bool) {
+			blockContent = ""
+		}
+		d.Set("content", blockContent)
+	case d.Get("store_content_hash_only").(bool):
+		d.Set("content", "")
+		d.Set("content_base64", "")
+	case d.Get("lfs").(bool):
+		// The content of an LFS file is not downloaded, but it's only kept if it still matches the pointer file.
+		stateContentSHA256, err := repositoryFileConfiguredContentSHA256(normalizeRepositoryFileContent(d.Get("content").(string), d.Get("normalize").(string)), d.Get("content_base64").(string))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		if stateContentSHA256 != contentSHA256 {
+			log.Printf("[DEBUG] LFS object of file %s has changed to %s", filePath, contentSHA256)
+			d.Set("content", "")
+			d.Set("content_base64", "")
+		}
+	case isRepositoryFileContentUpToDate(d, repositoryFile):
+		log.Printf("[DEBUG] file %s is unchanged since blob %s, skipping download of its content", filePath, repositoryFile.BlobID)
+	default:
+		repositoryFile, _, err = client.RepositoryFiles.GetFile(project, filePath, options)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		if err := setRepositoryFileContent(d, repositoryFile.Content); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	// Listing the repository tree for the filemode is expensive for large directories,
+	// therefore it's only done if the file has been changed by a commit since the last read
+	// or if it's expected to be executable.
+	if repositoryFile.LastCommitID != d.Get("last_commit_id").(string) || repositoryFile.BlobID != d.Get("blob_id").(string) || d.Get("executable").(bool) {
+		executable, err := isRepositoryFileExecutable(client, project, filePath, readBranch)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		d.Set("executable", executable)
+	}
+
+	d.Set("content_sha256", contentSHA256)
+	d.Set("last_commit_id", repositoryFile.LastCommitID)
+	d.Set("blob_id", repositoryFile.BlobID)
+
+	if err := refreshRepositoryFileMergeRequest(client, d); err != nil {
+		return diag.FromErr(err)
+	}
 
 	return nil
 }
 
+// isRepositoryFileContentUpToDate returns true if the content in the state belongs
+// to the file with the given metadata and therefore doesn't need to be downloaded.
+func isRepositoryFileContentUpToDate(d *schema.ResourceData, metaData *gitlab.File) bool {
+	if d.Get("blob_id").(string) != metaData.BlobID || d.Get("content_sha256").(string) != metaData.SHA256 {
+		return false
+	}
+
+	// the state may not contain any content, e.g. when only the hash has been stored before.
+	hasContent := d.Get("content").(string) != "" || d.Get("content_base64").(string) != ""
+	return hasContent || metaData.Size == 0
+}
+
 func resourceGitlabRepositoryFileUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*gitlab.Client)
+	client := meta.(*Meta).Client
 	project := d.Get("project").(string)
 	filePath := d.Get("file_path").(string)
 
-	readOptions := &gitlab.GetFileOptions{
-		Ref: gitlab.String(d.Get("branch").(string)),
+	// If only the content hash is stored, the configured content is only known if it has changed.
+	// Otherwise, the file would be updated with an empty content.
+	forceCommit := d.Get("force_commit").(bool)
+	updateContent := d.HasChange("content") || d.HasChange("content_base64") || d.HasChange("lfs") || d.HasChange("managed_block") || repositoryFileNormalizeChanged(d) || (forceCommit && !d.Get("store_content_hash_only").(bool))
+	updateFilemode := d.HasChange("executable")
+	previousFilePath, _ := d.GetChange("file_path")
+	move := d.HasChange("file_path")
+	if !updateContent && !updateFilemode && !move {
+		return resourceGitlabRepositoryFileRead(ctx, d, meta)
 	}
 
-	existingRepositoryFile, _, err := client.RepositoryFiles.GetFile(project, filePath, readOptions)
-	if err != nil {
-		return diag.FromErr(err)
+	var content string
+	if updateContent {
+		var err error
+		content, err = repositoryFileCommitContent(ctx, meta.(*Meta), d)
+		if err != nil {
+			return diag.FromErr(err)
+		}
 	}
 
-	options := &gitlab.UpdateFileOptions{
-		Branch:        gitlab.String(d.Get("branch").(string)),
-		Encoding:      gitlab.String(encoding),
-		AuthorEmail:   gitlab.String(d.Get("author_email").(string)),
-		AuthorName:    gitlab.String(d.Get("author_name").(string)),
-		Content:       gitlab.String(d.Get("content").(string)),
-		CommitMessage: gitlab.String(d.Get("commit_message").(string)),
-		LastCommitID:  gitlab.String(existingRepositoryFile.LastCommitID),
-	}
-	if startBranch, ok := d.GetOk("start_branch"); ok {
-		options.StartBranch = gitlab.String(startBranch.(string))
+	// the block is located with its previous markers, in case they have been changed.
+	block := expandManagedBlock(d.Get("managed_block"))
+	previousManagedBlock, _ := d.GetChange("managed_block")
+	previousBlock := expandManagedBlock(previousManagedBlock)
+	if previousBlock == nil {
+		previousBlock = block
 	}
 
-	_, _, err = client.RepositoryFiles.UpdateFile(project, filePath, options)
+	lockKey := repositoryBranchLockKey(project, d.Get("branch").(string))
+	lockRepositoryBranch(meta.(*Meta), lockKey)
+
+	// the last commit id is fetched again on every attempt, because the repository has likely
+	// changed if a retry is necessary. It's only actually fetched if concurrent changes are overwritten.
+	var lastCommitID string
+	var committed bool
+	var commitBranch string
+	err := retryRepositoryWrite(ctx, meta.(*Meta).Config, func() error {
+		branch, startBranch, err := repositoryFileWriteBranches(client, d)
+		if err != nil {
+			return err
+		}
+		lastCommitID, err = repositoryFileLastCommitID(client, d)
+		if err != nil {
+			return err
+		}
+
+		readBranch := branch
+		if startBranch != "" {
+			readBranch = startBranch
+		}
+
+		// the managed block is replaced in the current content of the file.
+		commitContent := content
+		if block != nil && updateContent {
+			fileContent, err := getRepositoryFileContent(client, project, previousFilePath.(string), readBranch)
+			if err != nil {
+				return err
+			}
+			commitContent = base64.StdEncoding.EncodeToString([]byte(block.Upsert(fileContent, previousBlock, d.Get("content").(string))))
+		}
+
+		// unless a commit is forced, only what actually differs from the branch is committed.
+		writeContent, writeFilemode := updateContent, updateFilemode
+		if !forceCommit {
+			if writeContent {
+				currentRepositoryFile, err := getExistingRepositoryFile(client, project, previousFilePath.(string), readBranch)
+				if err != nil {
+					return err
+				}
+				contentSHA256, err := repositoryFileConfiguredContentSHA256("", commitContent)
+				if err != nil {
+					return err
+				}
+				writeContent = currentRepositoryFile == nil || currentRepositoryFile.SHA256 != contentSHA256
+			}
+			if writeFilemode {
+				executable, err := isRepositoryFileExecutable(client, project, previousFilePath.(string), readBranch)
+				if err != nil {
+					return err
+				}
+				writeFilemode = executable != d.Get("executable").(bool)
+			}
+		}
+		if !move && !writeContent && !writeFilemode {
+			log.Printf("[DEBUG] file %s is already up to date on branch %s, skipping commit", filePath, branch)
+			committed = false
+			return nil
+		}
+
+		actions := []*gitlab.CommitActionOptions{}
+		switch {
+		case move:
+			// a move keeps the content of the file unless a new content is given.
+			moveAction := &gitlab.CommitActionOptions{
+				Action:       gitlab.FileAction(gitlab.FileMove),
+				FilePath:     gitlab.String(filePath),
+				PreviousPath: gitlab.String(previousFilePath.(string)),
+				LastCommitID: gitlab.String(lastCommitID),
+			}
+			if writeContent {
+				moveAction.Content = gitlab.String(commitContent)
+				moveAction.Encoding = gitlab.String(encoding)
+			}
+			actions = append(actions, moveAction)
+		case writeContent:
+			actions = append(actions, &gitlab.CommitActionOptions{
+				Action:       gitlab.FileAction(gitlab.FileUpdate),
+				FilePath:     gitlab.String(filePath),
+				Content:      gitlab.String(commitContent),
+				Encoding:     gitlab.String(encoding),
+				LastCommitID: gitlab.String(lastCommitID),
+			})
+		}
+		if writeFilemode {
+			chmodAction := repositoryFileChmodAction(d)
+			chmodAction.LastCommitID = gitlab.String(lastCommitID)
+			actions = append(actions, chmodAction)
+		}
+
+		commitAction := commitActionUpdate
+		if move {
+			commitAction = commitActionMove
+		}
+
+		committed, commitBranch = true, branch
+		return commitRepositoryFile(client, d, branch, startBranch, commitMessage(meta.(*Meta), d, commitAction, filePath), actions)
+	})
+	if err == nil && committed {
+		deferRepositoryPipeline(meta.(*Meta), d, commitBranch)
+	}
+	// the branch is unlocked before the merge request is delivered, which may wait for its merge.
+	diags := unlockRepositoryBranch(meta.(*Meta), lockKey)
 	if err != nil {
-		return diag.FromErr(err)
+		if isRepositoryFileConflict(err) {
+			return append(diags, repositoryFileConflictDiagnostics(d, lastCommitID)...)
+		}
+		return append(diags, diag.FromErr(err)...)
 	}
 
-	return resourceGitlabRepositoryFileRead(ctx, d, meta)
+	if move {
+		d.SetId(buildRepositoryFileID(project, d.Get("branch").(string), filePath))
+	}
+
+	if committed && d.Get("delivery").(string) == deliveryMergeRequest {
+		if err := deliverRepositoryFileMergeRequest(ctx, client, d, d.Timeout(schema.TimeoutUpdate)); err != nil {
+			return append(diags, diag.FromErr(err)...)
+		}
+	}
+
+	return append(diags, resourceGitlabRepositoryFileRead(ctx, d, meta)...)
 }
 
 func resourceGitlabRepositoryFileDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*gitlab.Client)
+	client := meta.(*Meta).Client
 	project := d.Get("project").(string)
 	filePath := d.Get("file_path").(string)
 
-	readOptions := &gitlab.GetFileOptions{
-		Ref: gitlab.String(d.Get("branch").(string)),
+	if d.Get("on_destroy").(string) == onDestroyAbandon {
+		log.Printf("[DEBUG] abandoning file %s, it's kept in the repository", filePath)
+		return nil
 	}
 
-	existingRepositoryFile, _, err := client.RepositoryFiles.GetFile(project, filePath, readOptions)
+	// the original content is fetched up front, because blobs are immutable.
+	var originalContent []byte
+	restore := d.Get("on_destroy").(string) == onDestroyRestore && d.Get("original_blob_id").(string) != ""
+	if restore {
+		var err error
+		originalContent, _, err = client.Repositories.RawBlobContent(project, d.Get("original_blob_id").(string))
+		if err != nil {
+			return diag.Errorf("%s failed to get original blob %s of repository file: %v", d.Id(), d.Get("original_blob_id").(string), err)
+		}
+	}
+
+	lockKey := repositoryBranchLockKey(project, d.Get("branch").(string))
+	lockRepositoryBranch(meta.(*Meta), lockKey)
+
+	var lastCommitID string
+	var committed bool
+	var commitBranch string
+	err := retryRepositoryWrite(ctx, meta.(*Meta).Config, func() error {
+		branch, startBranch, err := repositoryFileWriteBranches(client, d)
+		if err != nil {
+			return err
+		}
+		lastCommitID, err = repositoryFileLastCommitID(client, d)
+		if err != nil {
+			return err
+		}
+
+		committed, commitBranch = true, branch
+		if restore {
+			return commitRepositoryFile(client, d, branch, startBranch, commitMessage(meta.(*Meta), d, commitActionRestore, filePath), []*gitlab.CommitActionOptions{
+				{
+					Action:       gitlab.FileAction(gitlab.FileUpdate),
+					FilePath:     gitlab.String(filePath),
+					Content:      gitlab.String(base64.StdEncoding.EncodeToString(originalContent)),
+					Encoding:     gitlab.String(encoding),
+					LastCommitID: gitlab.String(lastCommitID),
+				},
+			})
+		}
+
+		// only the managed block is removed, unless the file doesn't contain anything else.
+		if block := expandManagedBlock(d.Get("managed_block")); block != nil {
+			readBranch := branch
+			if startBranch != "" {
+				readBranch = startBranch
+			}
+			fileContent, err := getRepositoryFileContent(client, project, filePath, readBranch)
+			if err != nil {
+				return err
+			}
+			remainingContent := block.Remove(fileContent)
+			if remainingContent == fileContent {
+				log.Printf("[DEBUG] file %s doesn't contain the managed block anymore, skipping commit", filePath)
+				committed = false
+				return nil
+			}
+			if strings.TrimSpace(remainingContent) != "" {
+				return commitRepositoryFile(client, d, branch, startBranch, commitMessage(meta.(*Meta), d, commitActionDelete, filePath), []*gitlab.CommitActionOptions{
+					{
+						Action:       gitlab.FileAction(gitlab.FileUpdate),
+						FilePath:     gitlab.String(filePath),
+						Content:      gitlab.String(base64.StdEncoding.EncodeToString([]byte(remainingContent))),
+						Encoding:     gitlab.String(encoding),
+						LastCommitID: gitlab.String(lastCommitID),
+					},
+				})
+			}
+		}
+
+		options := &gitlab.DeleteFileOptions{
+			Branch:        gitlab.String(branch),
+			AuthorEmail:   gitlab.String(d.Get("author_email").(string)),
+			AuthorName:    gitlab.String(d.Get("author_name").(string)),
+			CommitMessage: gitlab.String(commitMessage(meta.(*Meta), d, commitActionDelete, filePath)),
+			LastCommitID:  gitlab.String(lastCommitID),
+		}
+		if startBranch != "" {
+			options.StartBranch = gitlab.String(startBranch)
+		}
+
+		_, err = client.RepositoryFiles.DeleteFile(project, filePath, options)
+		return err
+	})
+	if err == nil && committed {
+		deferRepositoryPipeline(meta.(*Meta), d, commitBranch)
+	}
+	// the branch is unlocked before the merge request is delivered, which may wait for its merge.
+	diags := unlockRepositoryBranch(meta.(*Meta), lockKey)
 	if err != nil {
-		return diag.FromErr(err)
+		if isRepositoryFileConflict(err) {
+			return append(diags, repositoryFileConflictDiagnostics(d, lastCommitID)...)
+		}
+		if restore {
+			return append(diags, diag.Errorf("%s failed to restore repository file: %v", d.Id(), err)...)
+		}
+		return append(diags, diag.Errorf("%s failed to delete repository file: %v", d.Id(), err)...)
 	}
 
-	options := &gitlab.DeleteFileOptions{
-		Branch:        gitlab.String(d.Get("branch").(string)),
-		AuthorEmail:   gitlab.String(d.Get("author_email").(string)),
-		AuthorName:    gitlab.String(d.Get("author_name").(string)),
-		CommitMessage: gitlab.String(fmt.Sprintf("[DELETE]: %s", d.Get("commit_message").(string))),
-		LastCommitID:  gitlab.String(existingRepositoryFile.LastCommitID),
+	if committed && d.Get("delivery").(string) == deliveryMergeRequest {
+		if err := deliverRepositoryFileMergeRequest(ctx, client, d, d.Timeout(schema.TimeoutDelete)); err != nil {
+			return append(diags, diag.FromErr(err)...)
+		}
+	}
+
+	return diags
+}
+
+func resourceGitlabRepositoryFileCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	// a content change creates a new blob
+	if d.HasChange("content") || d.HasChange("content_base64") || d.HasChange("lfs") || d.HasChange("managed_block") || repositoryFileNormalizeChanged(d) {
+		contentSHA256, err := repositoryFileConfiguredContentSHA256(normalizeRepositoryFileContent(d.Get("content").(string), d.Get("normalize").(string)), d.Get("content_base64").(string))
+		if err != nil {
+			return err
+		}
+		if err := d.SetNew("content_sha256", contentSHA256); err != nil {
+			return err
+		}
+		if err := d.SetNewComputed("blob_id"); err != nil {
+			return err
+		}
 	}
 
-	resp, err := client.RepositoryFiles.DeleteFile(project, filePath, options)
+	// a content or filemode change and a move create a new commit
+	if d.HasChange("content") || d.HasChange("content_base64") || d.HasChange("lfs") || d.HasChange("managed_block") || repositoryFileNormalizeChanged(d) || d.HasChange("executable") || d.HasChange("file_path") {
+		if err := d.SetNewComputed("last_commit_id"); err != nil {
+			return err
+		}
+		if d.Get("delivery").(string) == deliveryMergeRequest {
+			if err := d.SetNewComputed("merge_request_merge_commit_sha"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// repositoryFileIfExists returns what happens if the file to create does already exist.
+// The deprecated `overwrite_on_create` is still honored if `if_exists` isn't set.
+func repositoryFileIfExists(d *schema.ResourceData) string {
+	if ifExists, ok := d.GetOk("if_exists"); ok {
+		return ifExists.(string)
+	}
+	if d.Get("overwrite_on_create").(bool) {
+		return ifExistsOverwrite
+	}
+	return ifExistsFail
+}
+
+// getExistingRepositoryFile returns the metadata of the file if it exists on the given branch.
+// Unlike a missing file, any other error, e.g. missing permissions, is returned.
+func getExistingRepositoryFile(client *gitlab.Client, project, filePath, branch string) (*gitlab.File, error) {
+	repositoryFile, resp, err := client.RepositoryFiles.GetFileMetaData(project, filePath, &gitlab.GetFileMetaDataOptions{Ref: gitlab.String(branch)})
 	if err != nil {
-		return diag.Errorf("%s failed to delete repository file: (%s) %v", d.Id(), resp.Status, err)
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		// the error isn't wrapped, so that it can still be retried.
+		return nil, err
+	}
+	return repositoryFile, nil
+}
+
+// getRepositoryFileContent returns the decoded content of the file on the given branch.
+func getRepositoryFileContent(client *gitlab.Client, project, filePath, branch string) (string, error) {
+	repositoryFile, _, err := client.RepositoryFiles.GetFile(project, filePath, &gitlab.GetFileOptions{Ref: gitlab.String(branch)})
+	if err != nil {
+		// the error isn't wrapped, so that it can still be retried.
+		return "", err
+	}
+
+	content, err := base64.StdEncoding.DecodeString(repositoryFile.Content)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode content of repository file %s: %v", filePath, err)
+	}
+	return string(content), nil
+}
+
+// repositoryFileLastCommitID returns the last commit id to send along with a change of the file.
+// This is the last commit id stored in the state, so that a change which has been made since the last
+// refresh leads to a conflict. If concurrent changes should be overwritten, or the state doesn't
+// contain a last commit id yet, the last commit id is fetched from the repository.
+func repositoryFileLastCommitID(client *gitlab.Client, d *schema.ResourceData) (string, error) {
+	if lastCommitID := d.Get("last_commit_id").(string); lastCommitID != "" && !d.Get("overwrite_concurrent_changes").(bool) {
+		return lastCommitID, nil
+	}
+
+	readBranch, err := repositoryFileReadBranch(client, d)
+	if err != nil {
+		return "", err
+	}
+	readOptions := &gitlab.GetFileOptions{
+		Ref: gitlab.String(readBranch),
+	}
+
+	// the file is still at its previous path if it's about to be moved.
+	filePath, _ := d.GetChange("file_path")
+	existingRepositoryFile, _, err := client.RepositoryFiles.GetFile(d.Get("project").(string), filePath.(string), readOptions)
+	if err != nil {
+		return "", err
+	}
+	return existingRepositoryFile.LastCommitID, nil
+}
+
+// isRepositoryFileConflict returns true if the given error was caused by a
+// last commit id which isn't the last commit id of the file anymore.
+func isRepositoryFileConflict(err error) bool {
+	errResponse, ok := err.(*gitlab.ErrorResponse)
+	if !ok || errResponse.Response == nil {
+		return false
+	}
+	return errResponse.Response.StatusCode == http.StatusBadRequest && strings.Contains(errResponse.Message, "has changed since")
+}
+
+func repositoryFileConflictDiagnostics(d *schema.ResourceData, lastCommitID string) diag.Diagnostics {
+	return diag.Diagnostics{
+		{
+			Severity: diag.Error,
+			Summary:  fmt.Sprintf("Repository file %s has been changed concurrently", d.Get("file_path").(string)),
+			Detail: fmt.Sprintf(
+				"The file has been changed on branch %s since it was last read at commit %s. "+
+					"Refresh the state to review the change or set overwrite_concurrent_changes to overwrite it.",
+				d.Get("branch").(string), lastCommitID,
+			),
+		},
+	}
+}
+
+// repositoryFileContentBase64 returns the configured and normalized content of the file base64 encoded,
+// because that's the only encoding the API supports.
+func repositoryFileContentBase64(d *schema.ResourceData) string {
+	if contentBase64, ok := d.GetOk("content_base64"); ok {
+		return contentBase64.(string)
+	}
+	return base64.StdEncoding.EncodeToString([]byte(normalizeRepositoryFileContent(d.Get("content").(string), d.Get("normalize").(string))))
+}
+
+// repositoryFileNormalizeChanged returns true if the normalization of the content has been changed.
+// A state from before the normalization could be configured has none, which is the same as `none`.
+func repositoryFileNormalizeChanged(d interface {
+	GetChange(string) (interface{}, interface{})
+}) bool {
+	previous, normalize := d.GetChange("normalize")
+	if previous.(string) == "" {
+		previous = normalizeNone
+	}
+	return previous.(string) != normalize.(string)
+}
+
+// normalizeRepositoryFileContent returns the text content normalized as configured with `normalize`.
+func normalizeRepositoryFileContent(content, normalize string) string {
+	switch normalize {
+	case normalizeLF:
+		return strings.ReplaceAll(content, "\r\n", "\n")
+	case normalizeCRLF:
+		return strings.ReplaceAll(strings.ReplaceAll(content, "\r\n", "\n"), "\n", "\r\n")
+	case normalizeTrimTrailingNewline:
+		return strings.TrimRight(content, "\r\n")
 	}
+	return content
+}
+
+// setRepositoryFileContent sets the given base64 encoded content from the API
+// in the form which is configured. Imported files have no configured form
+// and always use `content_base64`.
+func setRepositoryFileContent(d *schema.ResourceData, contentBase64 string) error {
+	if _, ok := d.GetOk("content"); ok {
+		content, err := base64.StdEncoding.DecodeString(contentBase64)
+		if err != nil {
+			return fmt.Errorf("failed to decode content of repository file %s: %v", d.Id(), err)
+		}
 
+		// a content which only differs from the state by its normalization is kept as it is in the state.
+		stateContent, normalize := d.Get("content").(string), d.Get("normalize").(string)
+		if normalizeRepositoryFileContent(string(content), normalize) == normalizeRepositoryFileContent(stateContent, normalize) {
+			content = []byte(stateContent)
+		}
+		d.Set("content", string(content))
+		return nil
+	}
+
+	d.Set("content_base64", contentBase64)
 	return nil
 }
 
+// repositoryFileUseLFS returns true if the file should be stored in LFS.
+// Unless it's configured explicitly, this is the case if the file path
+// is tracked by LFS in the `.gitattributes` of the branch the file is committed to.
+func repositoryFileUseLFS(client *gitlab.Client, d *schema.ResourceData) (bool, error) {
+	// the content of a managed block is merged into the file, which isn't possible for LFS objects.
+	if expandManagedBlock(d.Get("managed_block")) != nil {
+		return false, nil
+	}
+
+	// GetOkExists is deprecated, but it is the only way to tell an explicit false from an unset bool.
+	if lfs, ok := d.GetOkExists("lfs"); ok {
+		return lfs.(bool), nil
+	}
+
+	// the .gitattributes are read from the branch the file is committed to or,
+	// if it doesn't exist yet, from the branch it's created from.
+	branch, startBranch, err := repositoryFileWriteBranches(client, d)
+	if err != nil {
+		return false, err
+	}
+	if startBranch != "" {
+		branch = startBranch
+	}
+
+	options := &gitlab.GetFileOptions{
+		Ref: gitlab.String(branch),
+	}
+	gitattributes, resp, err := client.RepositoryFiles.GetFile(d.Get("project").(string), ".gitattributes", options)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get .gitattributes: %v", err)
+	}
+
+	content, err := base64.StdEncoding.DecodeString(gitattributes.Content)
+	if err != nil {
+		return false, fmt.Errorf("failed to decode .gitattributes: %v", err)
+	}
+	return isLFSTrackedPath(string(content), d.Get("file_path").(string)), nil
+}
+
+// repositoryFileCommitContent returns the base64 encoded content to commit for the file.
+// The content of an LFS file is uploaded to the LFS storage and only its pointer file is committed.
+func repositoryFileCommitContent(ctx context.Context, meta *Meta, d *schema.ResourceData) (string, error) {
+	contentBase64 := repositoryFileContentBase64(d)
+	if !d.Get("lfs").(bool) {
+		return contentBase64, nil
+	}
+
+	content, err := base64.StdEncoding.DecodeString(contentBase64)
+	if err != nil {
+		return "", err
+	}
+	pointer, err := uploadLFSObject(ctx, meta, d.Get("project").(string), content)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload %s to LFS: %v", d.Get("file_path").(string), err)
+	}
+	return base64.StdEncoding.EncodeToString([]byte(pointer)), nil
+}
+
+// repositoryFileLFSObjectID returns the object id in the pointer file of an LFS file.
+func repositoryFileLFSObjectID(client *gitlab.Client, project, filePath string, options *gitlab.GetFileOptions) (string, error) {
+	repositoryFile, _, err := client.RepositoryFiles.GetFile(project, filePath, options)
+	if err != nil {
+		return "", err
+	}
+
+	pointer, err := base64.StdEncoding.DecodeString(repositoryFile.Content)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode content of repository file %s: %v", filePath, err)
+	}
+	oid, _, ok := parseLFSPointer(pointer)
+	if !ok {
+		// the file has been committed as a raw blob, its hash doesn't match any LFS object.
+		log.Printf("[WARN] file %s is not an LFS pointer file", filePath)
+		return "", nil
+	}
+	return oid, nil
+}
+
+// suppressRepositoryFileContentDiff suppresses the diff of the text content if only the hash of the content
+// is stored in the state and the configured content matches it, or if the contents are equal after normalizing them.
+func suppressRepositoryFileContentDiff(k, old, new string, d *schema.ResourceData) bool {
+	if suppressRepositoryFileContentHashOnlyDiff(k, old, new, d) {
+		return true
+	}
+
+	normalize := d.Get("normalize").(string)
+	return normalize != normalizeNone && old != "" && normalizeRepositoryFileContent(old, normalize) == normalizeRepositoryFileContent(new, normalize)
+}
+
+// suppressRepositoryFileContentHashOnlyDiff suppresses the diff of the content if only the
+// hash of the content is stored in the state and the configured content matches it.
+func suppressRepositoryFileContentHashOnlyDiff(k, old, new string, d *schema.ResourceData) bool {
+	if !d.Get("store_content_hash_only").(bool) || old != "" || new == "" {
+		return false
+	}
+
+	var contentSHA256 string
+	var err error
+	if k == "content_base64" {
+		contentSHA256, err = repositoryFileConfiguredContentSHA256("", new)
+	} else {
+		contentSHA256, err = repositoryFileConfiguredContentSHA256(normalizeRepositoryFileContent(new, d.Get("normalize").(string)), "")
+	}
+	if err != nil {
+		return false
+	}
+
+	return contentSHA256 == d.Get("content_sha256").(string)
+}
+
+// repositoryFileConfiguredContentSHA256 returns the SHA256 hex digest of the configured content,
+// which is either given as text or base64 encoded.
+func repositoryFileConfiguredContentSHA256(content, contentBase64 string) (string, error) {
+	rawContent := []byte(content)
+	if contentBase64 != "" {
+		decodedContent, err := base64.StdEncoding.DecodeString(contentBase64)
+		if err != nil {
+			return "", err
+		}
+		rawContent = decodedContent
+	}
+
+	return fmt.Sprintf("%x", sha256.Sum256(rawContent)), nil
+}
+
 func validateBase64Content(v interface{}, k string) (we []string, errors []error) {
 	content := v.(string)
 	if _, err := base64.StdEncoding.DecodeString(content); err != nil {
@@ -282,3 +1178,62 @@ func validateBase64Content(v interface{}, k string) (we []string, errors []error
 	}
 	return
 }
+
+// commitRepositoryFile commits the given actions for the file to the branch.
+// The Commits API is used instead of the Repository Files API,
+// because only it allows to change the execute filemode of a file.
+func commitRepositoryFile(client *gitlab.Client, d *schema.ResourceData, branch, startBranch, commitMessage string, actions []*gitlab.CommitActionOptions) error {
+	options := &gitlab.CreateCommitOptions{
+		Branch:        gitlab.String(branch),
+		AuthorEmail:   gitlab.String(d.Get("author_email").(string)),
+		AuthorName:    gitlab.String(d.Get("author_name").(string)),
+		CommitMessage: gitlab.String(commitMessage),
+		Actions:       actions,
+	}
+	if startBranch != "" {
+		options.StartBranch = gitlab.String(startBranch)
+	}
+
+	_, _, err := client.Commits.CreateCommit(d.Get("project").(string), options)
+	return err
+}
+
+// repositoryFileChmodAction returns the commit action which sets the configured execute filemode of the file.
+func repositoryFileChmodAction(d *schema.ResourceData) *gitlab.CommitActionOptions {
+	return &gitlab.CommitActionOptions{
+		Action:          gitlab.FileAction(gitlab.FileChmod),
+		FilePath:        gitlab.String(d.Get("file_path").(string)),
+		ExecuteFilemode: gitlab.Bool(d.Get("executable").(bool)),
+	}
+}
+
+// isRepositoryFileExecutable returns true if the file has the execute filemode set.
+// The Repository Files API doesn't expose the filemode, therefore it's looked up
+// in the repository tree of the directory containing the file.
+func isRepositoryFileExecutable(client *gitlab.Client, project, filePath, ref string) (bool, error) {
+	options := &gitlab.ListTreeOptions{
+		ListOptions: gitlab.ListOptions{PerPage: 100},
+		Ref:         gitlab.String(ref),
+	}
+	if i := strings.LastIndex(filePath, "/"); i >= 0 {
+		options.Path = gitlab.String(filePath[:i])
+	}
+
+	for {
+		nodes, resp, err := client.Repositories.ListTree(project, options)
+		if err != nil {
+			return false, fmt.Errorf("failed to list repository tree for %s: %v", filePath, err)
+		}
+
+		for _, node := range nodes {
+			if node.Path == filePath {
+				return node.Mode == executableFilemode, nil
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return false, nil
+		}
+		options.Page = resp.NextPage
+	}
+}
@@ -0,0 +1,296 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+
+	gitlab "github.com/xanzy/go-gitlab"
+)
+
+func resourceGitlabRepositoryFileLines() *schema.Resource {
+	return &schema.Resource{
+		// This description is used by the documentation generator and the language server.
+		Description: `This resource allows you to ensure that lines are present in a GitLab repository file, in any order
+
+Use it for line-based files like ` + "`.gitignore`" + `, ` + "`.dockerignore`" + ` or ` + "`CODEOWNERS`" + `, which are shared with others.
+All missing lines are appended to the file in a single commit and the file is created if it doesn't exist yet.
+Lines are compared exactly, except for a trailing carriage return.
+On destroy, only the lines which have been added by this resource are removed again.
+
+` + "```" + `hcl
+resource "gitlab-repository-files_gitlab_repository_file_lines" "this" {
+	project        = gitlab_project.foo.id
+	branch         = "main"
+	file_path      = ".gitignore"
+	lines          = ["*.tfstate", "*.tfstate.*", ".terraform/"]
+	author_email   = "meow@catnip.com"
+	author_name    = "Meow Meowington"
+	commit_message = "chore: ignore terraform files"
+}
+` + "```",
+
+		CreateContext: resourceGitlabRepositoryFileLinesCreate,
+		ReadContext:   resourceGitlabRepositoryFileLinesRead,
+		UpdateContext: resourceGitlabRepositoryFileLinesUpdate,
+		DeleteContext: resourceGitlabRepositoryFileLinesDelete,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(60 * time.Minute),
+			Update: schema.DefaultTimeout(60 * time.Minute),
+			Delete: schema.DefaultTimeout(60 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"project": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The ID of the project.",
+			},
+			"branch": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The name of the branch to which to commit to.",
+			},
+			"file_path": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The full path of the file. It must be relative to the root of the project without a leading slash `/`.",
+			},
+			"lines": {
+				Type:     schema.TypeSet,
+				Required: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.All(validation.StringIsNotEmpty, validation.StringDoesNotContainAny("\r\n")),
+				},
+				Description: "The lines which must be present in the file. Removing a line from the set removes it from the file, if it has been added by this resource.",
+			},
+			"author_email": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The email address of the commit author.",
+			},
+			"author_name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The name of the commit author.",
+			},
+			"commit_message": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The commit message.",
+			},
+			"commit_message_templates": commitMessageTemplatesSchema("Templates of the commit messages by action. They take precedence over the templates of the provider."),
+			"trailers":                 commitTrailersSchema(),
+			"co_authors":               commitCoAuthorsSchema(),
+			"skip_ci":                  commitSkipCISchema(),
+			"on_destroy": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      onDestroyDelete,
+				ValidateFunc: validation.StringInSlice([]string{onDestroyDelete, onDestroyAbandon}, false),
+				Description:  "What happens to the lines on destroy. Either `delete` to remove the `added_lines` from the file or `abandon` to leave them as they are. A file which has been created by this resource is deleted if nothing else is left.",
+			},
+			"added_lines": {
+				Type:        schema.TypeSet,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The lines which have been missing and have been added to the file by this resource.",
+			},
+			"created": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "If the file has been created by this resource.",
+			},
+			"last_commit_id": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The ID of the last commit which changed the file.",
+			},
+		},
+	}
+}
+
+func resourceGitlabRepositoryFileLinesCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	var created bool
+	var addedLines []string
+	diags := commitRepositoryFileLines(ctx, meta.(*Meta), d, commitActionCreate, func(content string, exists bool) (string, bool) {
+		created = !exists
+		content, addedLines = ensureRepositoryFileLines(d, content)
+		return content, false
+	})
+	if diags.HasError() {
+		return diags
+	}
+	d.Set("created", created)
+	d.Set("added_lines", addedLines)
+
+	d.SetId(buildRepositoryFileID(d.Get("project").(string), d.Get("branch").(string), d.Get("file_path").(string)))
+	return append(diags, resourceGitlabRepositoryFileLinesRead(ctx, d, meta)...)
+}
+
+func resourceGitlabRepositoryFileLinesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*Meta).Client
+	project, branch, filePath, err := parseRepositoryFileID(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	repositoryFile, resp, err := client.RepositoryFiles.GetFile(project, filePath, &gitlab.GetFileOptions{Ref: gitlab.String(branch)})
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			log.Printf("[WARN] file %s not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
+		return diag.Errorf("failed to get file %s: %v", filePath, err)
+	}
+	content, err := base64.StdEncoding.DecodeString(repositoryFile.Content)
+	if err != nil {
+		return diag.Errorf("failed to decode content of repository file %s: %v", filePath, err)
+	}
+
+	// only lines which are still present are stored, so that missing lines are added again.
+	d.Set("lines", presentFileLines(string(content), stringSetToSlice(d.Get("lines"))))
+	d.Set("added_lines", presentFileLines(string(content), stringSetToSlice(d.Get("added_lines"))))
+	d.Set("last_commit_id", repositoryFile.LastCommitID)
+	return nil
+}
+
+func resourceGitlabRepositoryFileLinesUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if d.HasChange("lines") {
+		var addedLines []string
+		diags = commitRepositoryFileLines(ctx, meta.(*Meta), d, commitActionUpdate, func(content string, exists bool) (string, bool) {
+			content, addedLines = ensureRepositoryFileLines(d, content)
+			return content, false
+		})
+		if diags.HasError() {
+			return diags
+		}
+		d.Set("added_lines", addedLines)
+	}
+	return append(diags, resourceGitlabRepositoryFileLinesRead(ctx, d, meta)...)
+}
+
+func resourceGitlabRepositoryFileLinesDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if d.Get("on_destroy").(string) == onDestroyAbandon {
+		log.Printf("[DEBUG] abandoning lines of file %s, they are kept in the repository", d.Get("file_path").(string))
+		return nil
+	}
+
+	return commitRepositoryFileLines(ctx, meta.(*Meta), d, commitActionDelete, func(content string, exists bool) (string, bool) {
+		content = removeFileLines(content, stringSetToSlice(d.Get("added_lines")))
+		return content, d.Get("created").(bool) && strings.TrimSpace(content) == ""
+	})
+}
+
+// ensureRepositoryFileLines returns the file content with the lines removed from the configuration,
+// which have been added by the resource, removed and the missing lines appended.
+// It also returns the lines which have been added by the resource afterwards. They are computed
+// from the state on every call, so that the result only depends on the given content.
+func ensureRepositoryFileLines(d *schema.ResourceData, content string) (string, []string) {
+	previousLines, _ := d.GetChange("lines")
+	lines := stringSetToSlice(d.Get("lines"))
+	isConfigured := fileLineSet(strings.Join(lines, "\n"))
+
+	var addedLines, removedLines []string
+	for _, line := range stringSetToSlice(d.Get("added_lines")) {
+		if isConfigured[line] {
+			addedLines = append(addedLines, line)
+		} else if previousLines.(*schema.Set).Contains(line) {
+			removedLines = append(removedLines, line)
+		}
+	}
+
+	content = removeFileLines(content, removedLines)
+	missingLines := missingFileLines(content, lines)
+	return appendFileLines(content, missingLines), append(addedLines, missingLines...)
+}
+
+// commitRepositoryFileLines commits the content returned by the edit function for the current content of the file,
+// unless it's unchanged. The file doesn't need to exist yet and is deleted if the edit function asks for it.
+// The file is fetched again on every attempt, so that the edit is always applied to its latest content.
+func commitRepositoryFileLines(ctx context.Context, meta *Meta, d *schema.ResourceData, action string, edit func(content string, exists bool) (string, bool)) diag.Diagnostics {
+	client := meta.Client
+	project := d.Get("project").(string)
+	branch := d.Get("branch").(string)
+	filePath := d.Get("file_path").(string)
+
+	lockKey := repositoryBranchLockKey(project, branch)
+	lockRepositoryBranch(meta, lockKey)
+
+	var committed bool
+	err := retryRepositoryWrite(ctx, meta.Config, func() error {
+		var content, lastCommitID string
+		repositoryFile, resp, err := client.RepositoryFiles.GetFile(project, filePath, &gitlab.GetFileOptions{Ref: gitlab.String(branch)})
+		if err != nil && (resp == nil || resp.StatusCode != http.StatusNotFound) {
+			// the error isn't wrapped, so that it can still be retried.
+			return err
+		}
+		exists := err == nil
+		if exists {
+			decodedContent, err := base64.StdEncoding.DecodeString(repositoryFile.Content)
+			if err != nil {
+				return fmt.Errorf("failed to decode content of repository file %s: %v", filePath, err)
+			}
+			content, lastCommitID = string(decodedContent), repositoryFile.LastCommitID
+		}
+
+		newContent, deleteFile := edit(content, exists)
+		commitAction := &gitlab.CommitActionOptions{
+			FilePath: gitlab.String(filePath),
+			Content:  gitlab.String(base64.StdEncoding.EncodeToString([]byte(newContent))),
+			Encoding: gitlab.String(encoding),
+		}
+		switch {
+		case exists && deleteFile:
+			commitAction = &gitlab.CommitActionOptions{
+				Action:       gitlab.FileAction(gitlab.FileDelete),
+				FilePath:     gitlab.String(filePath),
+				LastCommitID: gitlab.String(lastCommitID),
+			}
+		case exists && newContent != content:
+			commitAction.Action = gitlab.FileAction(gitlab.FileUpdate)
+			commitAction.LastCommitID = gitlab.String(lastCommitID)
+		case !exists && newContent != "":
+			commitAction.Action = gitlab.FileAction(gitlab.FileCreate)
+		default:
+			log.Printf("[DEBUG] lines of file %s are already up to date, skipping commit", filePath)
+			committed = false
+			return nil
+		}
+
+		committed = true
+		return commitRepositoryFile(client, d, branch, "", commitMessage(meta, d, action, filePath), []*gitlab.CommitActionOptions{commitAction})
+	})
+	if err == nil && committed {
+		deferRepositoryPipeline(meta, d, branch)
+	}
+	diags := unlockRepositoryBranch(meta, lockKey)
+	if err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
+	return diags
+}
+
+// stringSetToSlice returns the elements of a set of strings.
+func stringSetToSlice(v interface{}) []string {
+	elements := v.(*schema.Set).List()
+	s := make([]string, len(elements))
+	for i, element := range elements {
+		s[i] = element.(string)
+	}
+	return s
+}
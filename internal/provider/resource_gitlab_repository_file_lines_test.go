@@ -0,0 +1,58 @@
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccGitlabRepositoryFileLines_basic(t *testing.T) {
+	rInt := acctest.RandInt()
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		CheckDestroy:      testAccCheckGitlabRepositoryFileDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccGitlabRepositoryFileLinesConfig(rInt, `["*.tfstate", ".terraform/"]`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("gitlab-repository-files_gitlab_repository_file_lines.this", "created", "true"),
+					resource.TestCheckResourceAttr("gitlab-repository-files_gitlab_repository_file_lines.this", "added_lines.#", "2"),
+				),
+			},
+			{
+				Config: testAccGitlabRepositoryFileLinesConfig(rInt, `["*.tfstate"]`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("gitlab-repository-files_gitlab_repository_file_lines.this", "lines.#", "1"),
+					resource.TestCheckResourceAttr("gitlab-repository-files_gitlab_repository_file_lines.this", "added_lines.#", "1"),
+				),
+			},
+		},
+	})
+}
+
+func testAccGitlabRepositoryFileLinesConfig(rInt int, lines string) string {
+	return fmt.Sprintf(`
+resource "gitlab_project" "foo" {
+  name = "foo-%d"
+  description = "Terraform acceptance tests"
+
+  # So that acceptance tests can be run in a gitlab organization
+  # with no billing
+  visibility_level = "public"
+}
+
+resource "gitlab-repository-files_gitlab_repository_file_lines" "this" {
+  project = "${gitlab_project.foo.id}"
+  branch = "main"
+  file_path = ".gitignore"
+  lines = %s
+  author_email = "meow@catnip.com"
+  author_name = "Meow Meowington"
+  commit_message = "chore: ignore terraform files"
+}
+	`, rInt, lines)
+}
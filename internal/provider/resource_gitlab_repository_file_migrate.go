@@ -0,0 +1,211 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// resourceGitlabRepositoryFileResourceV0 is the schema of the repository file resource
+// before the `content` attribute was changed from base64 encoded to plain text.
+func resourceGitlabRepositoryFileResourceV0() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"project": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"file_path": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"branch": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"start_branch": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"author_email": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"author_name": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"content": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"commit_message": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"overwrite_on_create": {
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
+			"overwrite_concurrent_changes": {
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
+			"last_commit_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"blob_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+// resourceGitlabRepositoryFileStateUpgradeV0 moves the base64 encoded `content`
+// to `content_base64`, so that existing configurations only have to rename the attribute.
+func resourceGitlabRepositoryFileStateUpgradeV0(ctx context.Context, rawState map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+	log.Printf("[DEBUG] upgrading state of repository file %v from version 0", rawState["id"])
+
+	rawState["content_base64"] = rawState["content"]
+	delete(rawState, "content")
+
+	return rawState, nil
+}
+
+// resourceGitlabRepositoryFileResourceV1 is the schema of the repository file resource
+// before its ID was changed from the file path to a composite ID.
+func resourceGitlabRepositoryFileResourceV1() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"project": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"file_path": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"branch": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"start_branch": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"author_email": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"author_name": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"content": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"content_base64": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"store_content_hash_only": {
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
+			"content_sha256": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"lfs": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Computed: true,
+			},
+			"executable": {
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
+			"commit_message": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"overwrite_on_create": {
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
+			"overwrite_concurrent_changes": {
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
+			"delivery": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"merge_request_source_branch": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			"merge_request_title": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"merge_request_auto_merge": {
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
+			"merge_request_wait_for_merge": {
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
+			"merge_request_iid": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"merge_request_web_url": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"merge_request_merge_commit_sha": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"last_commit_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"blob_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+// resourceGitlabRepositoryFileStateUpgradeV1 replaces the file path in the ID
+// with a composite ID of the project, branch and file path.
+func resourceGitlabRepositoryFileStateUpgradeV1(ctx context.Context, rawState map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+	log.Printf("[DEBUG] upgrading state of repository file %v from version 1", rawState["id"])
+
+	project, _ := rawState["project"].(string)
+	branch, _ := rawState["branch"].(string)
+	filePath, _ := rawState["file_path"].(string)
+	if project == "" || branch == "" || filePath == "" {
+		return nil, fmt.Errorf("unable to build ID of repository file %v: missing project, branch or file path", rawState["id"])
+	}
+
+	rawState["id"] = buildRepositoryFileID(project, branch, filePath)
+
+	return rawState, nil
+}
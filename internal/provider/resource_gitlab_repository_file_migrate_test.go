@@ -0,0 +1,57 @@
+package provider
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestAccGitlabRepositoryFile_stateUpgradeV0(t *testing.T) {
+	givenState := map[string]interface{}{
+		"id":        "meow.txt",
+		"project":   "42",
+		"file_path": "meow.txt",
+		"branch":    "main",
+		"content":   "bWVvdyBtZW93IG1lb3c=",
+	}
+	expectedState := map[string]interface{}{
+		"id":             "meow.txt",
+		"project":        "42",
+		"file_path":      "meow.txt",
+		"branch":         "main",
+		"content_base64": "bWVvdyBtZW93IG1lb3c=",
+	}
+
+	actualState, err := resourceGitlabRepositoryFileStateUpgradeV0(context.Background(), givenState, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(actualState, expectedState) {
+		t.Fatalf("got state %v; want %v", actualState, expectedState)
+	}
+}
+
+func TestAccGitlabRepositoryFile_stateUpgradeV1(t *testing.T) {
+	givenState := map[string]interface{}{
+		"id":        "launch:codes.txt",
+		"project":   "42",
+		"file_path": "launch:codes.txt",
+		"branch":    "main",
+	}
+	expectedState := map[string]interface{}{
+		"id":        "v1:42:main:launch%3Acodes.txt",
+		"project":   "42",
+		"file_path": "launch:codes.txt",
+		"branch":    "main",
+	}
+
+	actualState, err := resourceGitlabRepositoryFileStateUpgradeV1(context.Background(), givenState, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(actualState, expectedState) {
+		t.Fatalf("got state %v; want %v", actualState, expectedState)
+	}
+}
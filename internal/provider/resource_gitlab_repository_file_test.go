@@ -1,10 +1,13 @@
 package provider
 
 import (
+	"encoding/base64"
 	"fmt"
+	"net/http"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	gitlab "github.com/xanzy/go-gitlab"
 )
@@ -55,6 +58,203 @@ func TestAccGitlabRepositoryFile_validationOfBase64Content(t *testing.T) {
 	}
 }
 
+func TestAccGitlabRepositoryFile_isRepositoryFileConflict(t *testing.T) {
+	cases := []struct {
+		givenErr           error
+		expectedIsConflict bool
+	}{
+		{
+			givenErr: &gitlab.ErrorResponse{
+				Response: &http.Response{StatusCode: http.StatusBadRequest},
+				Message:  "{message: You are attempting to update a file that has changed since you started editing it.}",
+			},
+			expectedIsConflict: true,
+		},
+		{
+			givenErr: &gitlab.ErrorResponse{
+				Response: &http.Response{StatusCode: http.StatusBadRequest},
+				Message:  "{message: A file with this name doesn't exist}",
+			},
+			expectedIsConflict: false,
+		},
+		{
+			givenErr:           fmt.Errorf("has changed since"),
+			expectedIsConflict: false,
+		},
+	}
+
+	for _, c := range cases {
+		if isRepositoryFileConflict(c.givenErr) != c.expectedIsConflict {
+			t.Fatalf("error '%v' was expected to be a conflict: %v", c.givenErr, c.expectedIsConflict)
+		}
+	}
+}
+
+func TestAccGitlabRepositoryFile_configuredContentSHA256(t *testing.T) {
+	cases := []struct {
+		givenContent       string
+		givenContentBase64 string
+		expectedSHA256     string
+	}{
+		{
+			givenContent:   "meow meow meow",
+			expectedSHA256: "9161b95bd541e0cf52a8e6dfced85fd1ca765120e74eff997b9045b9a206f74c",
+		},
+		{
+			givenContentBase64: "bWVvdyBtZW93IG1lb3c=",
+			expectedSHA256:     "9161b95bd541e0cf52a8e6dfced85fd1ca765120e74eff997b9045b9a206f74c",
+		},
+	}
+
+	for _, c := range cases {
+		actualSHA256, err := repositoryFileConfiguredContentSHA256(c.givenContent, c.givenContentBase64)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if actualSHA256 != c.expectedSHA256 {
+			t.Fatalf("got SHA256 %q; want %q", actualSHA256, c.expectedSHA256)
+		}
+	}
+}
+
+func TestAccGitlabRepositoryFile_normalizeContent(t *testing.T) {
+	cases := []struct {
+		givenContent    string
+		givenNormalize  string
+		expectedContent string
+	}{
+		{givenContent: "a\r\nb\r\n", givenNormalize: normalizeNone, expectedContent: "a\r\nb\r\n"},
+		{givenContent: "a\r\nb\n", givenNormalize: normalizeLF, expectedContent: "a\nb\n"},
+		{givenContent: "a\r\nb\n", givenNormalize: normalizeCRLF, expectedContent: "a\r\nb\r\n"},
+		{givenContent: "a\nb\r\n\n", givenNormalize: normalizeTrimTrailingNewline, expectedContent: "a\nb"},
+	}
+
+	for _, c := range cases {
+		if content := normalizeRepositoryFileContent(c.givenContent, c.givenNormalize); content != c.expectedContent {
+			t.Fatalf("got %q for %q normalized with %s; want %q", content, c.givenContent, c.givenNormalize, c.expectedContent)
+		}
+	}
+}
+
+func TestAccGitlabRepositoryFile_setNormalizedContent(t *testing.T) {
+	cases := []struct {
+		givenState      map[string]interface{}
+		givenContent    string
+		expectedContent string
+	}{
+		{
+			givenState:      map[string]interface{}{"content": "a\r\nb\r\n", "normalize": normalizeLF},
+			givenContent:    "a\nb\n",
+			expectedContent: "a\r\nb\r\n",
+		},
+		{
+			givenState:      map[string]interface{}{"content": "a\r\nb\r\n", "normalize": normalizeLF},
+			givenContent:    "a\nc\n",
+			expectedContent: "a\nc\n",
+		},
+		{
+			givenState:      map[string]interface{}{"content": "a\r\nb\r\n"},
+			givenContent:    "a\nb\n",
+			expectedContent: "a\nb\n",
+		},
+		{
+			givenState:      map[string]interface{}{"content": "meow\n", "normalize": normalizeTrimTrailingNewline},
+			givenContent:    "meow",
+			expectedContent: "meow\n",
+		},
+	}
+
+	for _, c := range cases {
+		d := schema.TestResourceDataRaw(t, resourceGitlabRepositoryFile().Schema, c.givenState)
+		if err := setRepositoryFileContent(d, base64.StdEncoding.EncodeToString([]byte(c.givenContent))); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if content := d.Get("content").(string); content != c.expectedContent {
+			t.Fatalf("got content %q for state %v and file content %q; want %q", content, c.givenState, c.givenContent, c.expectedContent)
+		}
+	}
+}
+
+func TestAccGitlabRepositoryFile_isContentUpToDate(t *testing.T) {
+	cases := []struct {
+		givenState         map[string]interface{}
+		givenMetaData      *gitlab.File
+		expectedIsUpToDate bool
+	}{
+		{
+			givenState:         map[string]interface{}{"blob_id": "b1", "content_sha256": "s1", "content": "meow"},
+			givenMetaData:      &gitlab.File{BlobID: "b1", SHA256: "s1", Size: 4},
+			expectedIsUpToDate: true,
+		},
+		{
+			givenState:         map[string]interface{}{"blob_id": "b1", "content_sha256": "s1", "content": "meow"},
+			givenMetaData:      &gitlab.File{BlobID: "b2", SHA256: "s2", Size: 4},
+			expectedIsUpToDate: false,
+		},
+		{
+			givenState:         map[string]interface{}{"blob_id": "b1", "content_sha256": "s1"},
+			givenMetaData:      &gitlab.File{BlobID: "b1", SHA256: "s1", Size: 4},
+			expectedIsUpToDate: false,
+		},
+		{
+			givenState:         map[string]interface{}{"blob_id": "b1", "content_sha256": "s1"},
+			givenMetaData:      &gitlab.File{BlobID: "b1", SHA256: "s1", Size: 0},
+			expectedIsUpToDate: true,
+		},
+	}
+
+	for _, c := range cases {
+		d := schema.TestResourceDataRaw(t, resourceGitlabRepositoryFile().Schema, c.givenState)
+		if isRepositoryFileContentUpToDate(d, c.givenMetaData) != c.expectedIsUpToDate {
+			t.Fatalf("state %v with metadata %+v was expected to be up to date: %v", c.givenState, c.givenMetaData, c.expectedIsUpToDate)
+		}
+	}
+}
+
+func TestAccGitlabRepositoryFile_ifExists(t *testing.T) {
+	cases := []struct {
+		givenConfig      map[string]interface{}
+		expectedIfExists string
+	}{
+		{
+			givenConfig:      map[string]interface{}{},
+			expectedIfExists: ifExistsFail,
+		},
+		{
+			givenConfig:      map[string]interface{}{"overwrite_on_create": true},
+			expectedIfExists: ifExistsOverwrite,
+		},
+		{
+			givenConfig:      map[string]interface{}{"if_exists": "adopt_if_identical"},
+			expectedIfExists: ifExistsAdoptIfIdentical,
+		},
+	}
+
+	for _, c := range cases {
+		d := schema.TestResourceDataRaw(t, resourceGitlabRepositoryFile().Schema, c.givenConfig)
+		if ifExists := repositoryFileIfExists(d); ifExists != c.expectedIfExists {
+			t.Fatalf("got %q for %v; want %q", ifExists, c.givenConfig, c.expectedIfExists)
+		}
+	}
+}
+
+func TestAccGitlabRepositoryFile_chmodAction(t *testing.T) {
+	for _, executable := range []bool{true, false} {
+		d := schema.TestResourceDataRaw(t, resourceGitlabRepositoryFile().Schema, map[string]interface{}{
+			"file_path":  "hooks/pre-commit",
+			"executable": executable,
+		})
+
+		action := repositoryFileChmodAction(d)
+		if *action.Action != gitlab.FileChmod || *action.FilePath != "hooks/pre-commit" {
+			t.Fatalf("got %s action for %s; want chmod action for hooks/pre-commit", *action.Action, *action.FilePath)
+		}
+		if action.ExecuteFilemode == nil || *action.ExecuteFilemode != executable {
+			t.Fatalf("got execute filemode %v; want %v", action.ExecuteFilemode, executable)
+		}
+	}
+}
+
 // func TestAccGitlabRepositoryFile_createOnNewBranch(t *testing.T) {
 // 	var file gitlab.File
 // 	rInt := acctest.RandInt()
@@ -123,7 +323,7 @@ func TestAccGitlabRepositoryFile_validationOfBase64Content(t *testing.T) {
 // 	// setup function to test when project is managed outside of terraform
 // 	projectId, err := func() (int, error) {
 // 		testAccProvider, _ := providerFactories["gitlab-repository-files"]()
-// 		client := testAccProvider.Meta().(*gitlab.Client)
+// 		client := testAccProvider.Meta().(*Meta).Client
 
 // 		createProjectOptions := &gitlab.CreateProjectOptions{
 // 			Name:                 gitlab.String(fmt.Sprintf("foo-%d", rInt)),
@@ -159,7 +359,7 @@ func TestAccGitlabRepositoryFile_validationOfBase64Content(t *testing.T) {
 
 // 	defer func(projectId int) {
 // 		testAccProvider, _ := providerFactories["gitlab-repository-files"]()
-// 		client := testAccProvider.Meta().(*gitlab.Client)
+// 		client := testAccProvider.Meta().(*Meta).Client
 
 // 		_, err := client.Projects.DeleteProject(projectId, nil)
 // 		if err != nil {
@@ -255,7 +455,7 @@ func testAccCheckGitlabRepositoryFileExists(n string, file *gitlab.File) resourc
 
 		testAccProvider, _ := providerFactories["gitlab-repository-files"]()
 
-		conn := testAccProvider.Meta().(*gitlab.Client)
+		conn := testAccProvider.Meta().(*Meta).Client
 
 		gotFile, _, err := conn.RepositoryFiles.GetFile(repoName, fileID, options)
 		if err != nil {
@@ -290,7 +490,7 @@ func testAccCheckGitlabRepositoryFileAttributes(got *gitlab.File, want *testAccG
 
 func testAccCheckGitlabRepositoryFileDestroy(s *terraform.State) error {
 	testAccProvider, _ := providerFactories["gitlab-repository-files"]()
-	conn := testAccProvider.Meta().(*gitlab.Client)
+	conn := testAccProvider.Meta().(*Meta).Client
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "gitlab_project" {
@@ -331,7 +531,7 @@ resource "gitlab-repository-files_gitlab-repository-files_gitlab_repository_file
   project = "${gitlabx_project.foo.id}"
   file_path = "meow.txt"
   branch = "main"
-  content = "bWVvdyBtZW93IG1lb3c="
+  content_base64 = "bWVvdyBtZW93IG1lb3c="
   author_email = "meow@catnip.com"
   author_name = "Meow Meowington"
   commit_message = "feature: add launch codes"
@@ -358,7 +558,7 @@ resource "gitlab-repository-files_gitlab_repository_file" "this" {
   file_path = "meow.txt"
   branch = "main"
   start_branch = "meow-branch"
-  content = "bWVvdyBtZW93IG1lb3c="
+  content_base64 = "bWVvdyBtZW93IG1lb3c="
   author_email = "meow@catnip.com"
   author_name = "Meow Meowington"
   commit_message = "feature: add launch codes"
@@ -384,7 +584,7 @@ resource "gitlab-repository-files_gitlab_repository_file" "this" {
   project = "${gitlab_project.foo.id}"
   file_path = "meow.txt"
   branch = "main"
-  content = "bWVvdyBtZW93IG1lb3cgbWVvdyBtZW93Cg=="
+  content_base64 = "bWVvdyBtZW93IG1lb3cgbWVvdyBtZW93Cg=="
   author_email = "meow@catnip.com"
   author_name = "Meow Meowington"
   commit_message = "feature: change launch codes"
@@ -398,7 +598,7 @@ resource "gitlab-repository-files_gitlab_repository_file" "this_overwrite" {
   project = "%d"
   file_path = "meow.txt"
   branch = "main"
-  content = "bWVvdyBtZW93IG1lb3cgbWVvdyBtZW93Cg=="
+  content_base64 = "bWVvdyBtZW93IG1lb3cgbWVvdyBtZW93Cg=="
   author_email = "meow@catnip.com"
   author_name = "Meow Meowington"
   commit_message = "feature: overwrite launch codes"
@@ -0,0 +1,386 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/structure"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+
+	gitlab "github.com/xanzy/go-gitlab"
+)
+
+func resourceGitlabRepositoryFileValue() *schema.Resource {
+	return &schema.Resource{
+		// This description is used by the documentation generator and the language server.
+		Description: `This resource allows you to manage a single value of a structured JSON, YAML or TOML repository file
+
+The file must already exist. Only the value at the ` + "`document_path`" + ` is changed, while the rest of the file is kept.
+The formatting of the file is kept as much as possible: files are only changed at the value itself,
+so that the formatting of the other values and the comments of YAML and TOML files are kept.
+New values are written with the indentation and the line endings of the file.
+A commit is only made if the value in the file differs from the configured value.
+
+` + "```" + `hcl
+resource "gitlab-repository-files_gitlab_repository_file_value" "this" {
+	project        = gitlab_project.foo.id
+	branch         = "main"
+	file_path      = "package.json"
+	document_path  = "/scripts/build"
+	value          = jsonencode("tsc --build")
+	author_email   = "meow@catnip.com"
+	author_name    = "Meow Meowington"
+	commit_message = "feature: update build script"
+}
+` + "```",
+
+		CreateContext: resourceGitlabRepositoryFileValueCreate,
+		ReadContext:   resourceGitlabRepositoryFileValueRead,
+		UpdateContext: resourceGitlabRepositoryFileValueUpdate,
+		DeleteContext: resourceGitlabRepositoryFileValueDelete,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(60 * time.Minute),
+			Update: schema.DefaultTimeout(60 * time.Minute),
+			Delete: schema.DefaultTimeout(60 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"project": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The ID of the project.",
+			},
+			"branch": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The name of the branch to which to commit to.",
+			},
+			"file_path": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The full path of the structured file. It must be relative to the root of the project without a leading slash `/`.",
+			},
+			"format": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringInSlice(structuredFormats, false),
+				Description:  "The format of the file. Either `json`, `yaml` or `toml`. Defaults to the format of the extension of the `file_path`.",
+			},
+			"document_path": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateDocumentPath,
+				Description:  "The path of the value in the file. Either a JSON pointer like `/scripts/build` or a dotted path like `scripts.build`. Array elements are addressed by their index. An element is appended to an array by addressing the index after its last element, the JSON pointer index `-` isn't supported. Missing objects on the way are created.",
+			},
+			"value": {
+				Type:             schema.TypeString,
+				Required:         true,
+				ValidateFunc:     validation.StringIsJSON,
+				DiffSuppressFunc: structure.SuppressJsonDiff,
+				Description:      "The JSON encoded value, e.g. using `jsonencode()`. In TOML files, `null` isn't supported and tables can't be replaced as a whole.",
+			},
+			"author_email": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The email address of the commit author.",
+			},
+			"author_name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The name of the commit author.",
+			},
+			"commit_message": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The commit message.",
+			},
+			"commit_message_templates": commitMessageTemplatesSchema("Templates of the commit messages by action. They take precedence over the templates of the provider."),
+			"trailers":                 commitTrailersSchema(),
+			"co_authors":               commitCoAuthorsSchema(),
+			"skip_ci":                  commitSkipCISchema(),
+			"on_destroy": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      onDestroyDelete,
+				ValidateFunc: validation.StringInSlice([]string{onDestroyDelete, onDestroyAbandon}, false),
+				Description:  "What happens to the value on destroy. Either `delete` to remove it from the file or `abandon` to leave it as it is. A value which already existed before it was taken over is restored to its `original_value` instead of being removed.",
+			},
+			"original_value": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The JSON encoded value, which the file had at the `document_path` before it was taken over. It's empty if there was no value.",
+			},
+			"last_commit_id": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The ID of the last commit which changed the file.",
+			},
+		},
+	}
+}
+
+func resourceGitlabRepositoryFileValueCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	project := d.Get("project").(string)
+	branch := d.Get("branch").(string)
+	filePath := d.Get("file_path").(string)
+
+	if d.Get("format").(string) == "" {
+		format := structuredFileFormat(filePath)
+		if format == "" {
+			return diag.Errorf("the format of file %s can't be determined by its extension, set `format` explicitly", filePath)
+		}
+		d.Set("format", format)
+	}
+
+	diags := setRepositoryFileValue(ctx, meta.(*Meta), d, commitActionCreate)
+	if diags.HasError() {
+		return diags
+	}
+
+	d.SetId(buildRepositoryFileValueID(project, branch, filePath, d.Get("document_path").(string)))
+	return append(diags, resourceGitlabRepositoryFileValueRead(ctx, d, meta)...)
+}
+
+func resourceGitlabRepositoryFileValueRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*Meta).Client
+	project := d.Get("project").(string)
+	filePath := d.Get("file_path").(string)
+
+	repositoryFile, resp, err := client.RepositoryFiles.GetFile(project, filePath, &gitlab.GetFileOptions{Ref: gitlab.String(d.Get("branch").(string))})
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			log.Printf("[WARN] file %s of value %s not found, removing from state", filePath, d.Id())
+			d.SetId("")
+			return nil
+		}
+		return diag.Errorf("failed to get file %s: %v", filePath, err)
+	}
+
+	document, err := parseRepositoryFileDocument(d, repositoryFile)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	keys, err := parseDocumentPath(d.Get("document_path").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	value, ok, err := document.Get(keys)
+	if err != nil {
+		return diag.Errorf("failed to get %s of file %s: %v", d.Get("document_path").(string), filePath, err)
+	}
+
+	// a missing value is stored as an empty string, so that it's set again.
+	if ok {
+		d.Set("value", encodeOrderedJSON(value, ""))
+	} else {
+		d.Set("value", "")
+	}
+	d.Set("last_commit_id", repositoryFile.LastCommitID)
+	return nil
+}
+
+func resourceGitlabRepositoryFileValueUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if d.HasChange("value") {
+		diags = setRepositoryFileValue(ctx, meta.(*Meta), d, commitActionUpdate)
+		if diags.HasError() {
+			return diags
+		}
+	}
+	return append(diags, resourceGitlabRepositoryFileValueRead(ctx, d, meta)...)
+}
+
+func resourceGitlabRepositoryFileValueDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*Meta).Client
+	filePath := d.Get("file_path").(string)
+
+	if d.Get("on_destroy").(string) == onDestroyAbandon {
+		log.Printf("[DEBUG] abandoning value %s of file %s, it's kept in the repository", d.Get("document_path").(string), filePath)
+		return nil
+	}
+
+	existingRepositoryFile, err := getExistingRepositoryFile(client, d.Get("project").(string), filePath, d.Get("branch").(string))
+	if err != nil {
+		return diag.Errorf("failed to get file %s: %v", filePath, err)
+	}
+	if existingRepositoryFile == nil {
+		log.Printf("[DEBUG] file %s has already been deleted", filePath)
+		return nil
+	}
+
+	keys, err := parseDocumentPath(d.Get("document_path").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	// a value which existed before it was taken over is restored instead of removed.
+	if originalValue := d.Get("original_value").(string); originalValue != "" {
+		value, err := decodeOrderedJSON(originalValue)
+		if err != nil {
+			return diag.Errorf("failed to decode original value: %v", err)
+		}
+		return patchRepositoryFileValue(ctx, meta.(*Meta), d, commitActionRestore, func(document structuredDocument) (bool, error) {
+			currentValue, ok, err := document.Get(keys)
+			if err != nil {
+				return false, err
+			}
+			if ok && structuredValuesEqual(currentValue, value) {
+				return false, nil
+			}
+			return true, document.Set(keys, value)
+		})
+	}
+
+	return patchRepositoryFileValue(ctx, meta.(*Meta), d, commitActionDelete, func(document structuredDocument) (bool, error) {
+		_, ok, err := document.Get(keys)
+		if err != nil || !ok {
+			return false, err
+		}
+		return true, document.Remove(keys)
+	})
+}
+
+// setRepositoryFileValue sets the configured value in the file, unless it already has the value.
+func setRepositoryFileValue(ctx context.Context, meta *Meta, d *schema.ResourceData, action string) diag.Diagnostics {
+	keys, err := parseDocumentPath(d.Get("document_path").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	value, err := decodeOrderedJSON(d.Get("value").(string))
+	if err != nil {
+		return diag.Errorf("failed to decode value: %v", err)
+	}
+
+	return patchRepositoryFileValue(ctx, meta, d, action, func(document structuredDocument) (bool, error) {
+		currentValue, ok, err := document.Get(keys)
+		if err != nil {
+			return false, err
+		}
+		if action == commitActionCreate {
+			// the value the file had before is recorded, so that it's restored on destroy.
+			if ok {
+				d.Set("original_value", encodeOrderedJSON(currentValue, ""))
+			} else {
+				d.Set("original_value", "")
+			}
+		}
+		if ok && structuredValuesEqual(currentValue, value) {
+			return false, nil
+		}
+		return true, document.Set(keys, value)
+	})
+}
+
+// patchRepositoryFileValue applies the patch to the document of the file and commits the file if the patch changed it.
+// The file is fetched again on every attempt, so that the patch is always applied to its latest content.
+func patchRepositoryFileValue(ctx context.Context, meta *Meta, d *schema.ResourceData, action string, patch func(document structuredDocument) (bool, error)) diag.Diagnostics {
+	client := meta.Client
+	project := d.Get("project").(string)
+	branch := d.Get("branch").(string)
+	filePath := d.Get("file_path").(string)
+
+	lockKey := repositoryBranchLockKey(project, branch)
+	lockRepositoryBranch(meta, lockKey)
+
+	var committed bool
+	err := retryRepositoryWrite(ctx, meta.Config, func() error {
+		repositoryFile, _, err := client.RepositoryFiles.GetFile(project, filePath, &gitlab.GetFileOptions{Ref: gitlab.String(branch)})
+		if err != nil {
+			// the error isn't wrapped, so that it can still be retried.
+			return err
+		}
+
+		document, err := parseRepositoryFileDocument(d, repositoryFile)
+		if err != nil {
+			return err
+		}
+		changed, err := patch(document)
+		if err != nil {
+			return fmt.Errorf("failed to patch %s of file %s: %v", d.Get("document_path").(string), filePath, err)
+		}
+		if !changed {
+			log.Printf("[DEBUG] value %s of file %s is already up to date, skipping commit", d.Get("document_path").(string), filePath)
+			committed = false
+			return nil
+		}
+		content, err := document.String()
+		if err != nil {
+			return fmt.Errorf("failed to encode file %s: %v", filePath, err)
+		}
+
+		committed = true
+		return commitRepositoryFile(client, d, branch, "", commitMessage(meta, d, action, filePath), []*gitlab.CommitActionOptions{
+			{
+				Action:       gitlab.FileAction(gitlab.FileUpdate),
+				FilePath:     gitlab.String(filePath),
+				Content:      gitlab.String(base64.StdEncoding.EncodeToString([]byte(content))),
+				Encoding:     gitlab.String(encoding),
+				LastCommitID: gitlab.String(repositoryFile.LastCommitID),
+			},
+		})
+	})
+	if err == nil && committed {
+		deferRepositoryPipeline(meta, d, branch)
+	}
+	diags := unlockRepositoryBranch(meta, lockKey)
+	if err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
+	return diags
+}
+
+// parseRepositoryFileDocument returns the parsed content of the structured repository file.
+func parseRepositoryFileDocument(d *schema.ResourceData, repositoryFile *gitlab.File) (structuredDocument, error) {
+	content, err := base64.StdEncoding.DecodeString(repositoryFile.Content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode content of repository file %s: %v", repositoryFile.FilePath, err)
+	}
+
+	document, err := parseStructuredDocument(d.Get("format").(string), string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse repository file %s: %v", repositoryFile.FilePath, err)
+	}
+	return document, nil
+}
+
+// structuredValuesEqual returns true if the decoded JSON values are equal regardless of the order of keys
+// and the representation of numbers.
+func structuredValuesEqual(a, b interface{}) bool {
+	var decodedA, decodedB interface{}
+	if err := json.Unmarshal([]byte(encodeOrderedJSON(a, "")), &decodedA); err != nil {
+		return false
+	}
+	if err := json.Unmarshal([]byte(encodeOrderedJSON(b, "")), &decodedB); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(decodedA, decodedB)
+}
+
+func validateDocumentPath(v interface{}, k string) (we []string, errors []error) {
+	keys, err := parseDocumentPath(v.(string))
+	if err != nil {
+		errors = append(errors, fmt.Errorf("%q must be a document path: %v", k, err))
+		return
+	}
+	for _, key := range keys {
+		// the appended element couldn't be addressed by the same path afterwards, so every apply would append again.
+		if key == "-" {
+			errors = append(errors, fmt.Errorf("%q must not contain the index `-`, address the index after the last element of the array to append to it", k))
+		}
+	}
+	return
+}
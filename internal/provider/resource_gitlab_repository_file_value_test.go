@@ -0,0 +1,73 @@
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccGitlabRepositoryFileValue_basic(t *testing.T) {
+	rInt := acctest.RandInt()
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		CheckDestroy:      testAccCheckGitlabRepositoryFileDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccGitlabRepositoryFileValueConfig(rInt, "tsc --build"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("gitlab-repository-files_gitlab_repository_file_value.this", "format", "json"),
+					resource.TestCheckResourceAttr("gitlab-repository-files_gitlab_repository_file_value.this", "value", `"tsc --build"`),
+					resource.TestCheckResourceAttr("gitlab-repository-files_gitlab_repository_file_value.this", "original_value", `"make"`),
+				),
+			},
+			{
+				Config: testAccGitlabRepositoryFileValueConfig(rInt, "tsc --build --verbose"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("gitlab-repository-files_gitlab_repository_file_value.this", "value", `"tsc --build --verbose"`),
+				),
+			},
+		},
+	})
+}
+
+func testAccGitlabRepositoryFileValueConfig(rInt int, buildScript string) string {
+	return fmt.Sprintf(`
+resource "gitlab_project" "foo" {
+  name = "foo-%d"
+  description = "Terraform acceptance tests"
+
+  # So that acceptance tests can be run in a gitlab organization
+  # with no billing
+  visibility_level = "public"
+}
+
+resource "gitlab-repository-files_gitlab_repository_file" "this" {
+  project = "${gitlab_project.foo.id}"
+  file_path = "package.json"
+  branch = "main"
+  content = jsonencode({ name = "meow", scripts = { build = "make" } })
+  author_email = "meow@catnip.com"
+  author_name = "Meow Meowington"
+  commit_message = "feature: add package.json"
+
+  lifecycle {
+    ignore_changes = [content]
+  }
+}
+
+resource "gitlab-repository-files_gitlab_repository_file_value" "this" {
+  project = gitlab-repository-files_gitlab_repository_file.this.project
+  branch = gitlab-repository-files_gitlab_repository_file.this.branch
+  file_path = gitlab-repository-files_gitlab_repository_file.this.file_path
+  document_path = "/scripts/build"
+  value = jsonencode(%q)
+  author_email = "meow@catnip.com"
+  author_name = "Meow Meowington"
+  commit_message = "feature: update build script"
+}
+	`, rInt, buildScript)
+}
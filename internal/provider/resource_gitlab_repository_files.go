@@ -0,0 +1,382 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+
+	gitlab "github.com/xanzy/go-gitlab"
+)
+
+func resourceGitlabRepositoryFiles() *schema.Resource {
+	return &schema.Resource{
+		// This description is used by the documentation generator and the language server.
+		Description: `This resource allows you to create and manage multiple GitLab repository files in a single commit
+
+All file creations, updates and deletions of an apply are sent as one commit using the
+[GitLab Commits API](https://docs.gitlab.com/ee/api/commits.html#create-a-commit-with-multiple-files-and-actions).
+Thus, changing multiple files only produces a single commit and a single pipeline.
+
+A file block with ` + "`action = \"delete\"`" + ` makes sure that the given file does not exist in the repository.
+
+` + "```" + `hcl
+resource "gitlab-repository-files_gitlab_repository_files" "this" {
+	project        = gitlab_project.foo.id
+	branch         = "main"
+	author_email   = "meow@catnip.com"
+	author_name    = "Meow Meowington"
+	commit_message = "feature: add launch codes"
+
+	file {
+		file_path = "meow.txt"
+		content   = base64encode("hello world")
+	}
+
+	file {
+		file_path = "launch/codes.txt"
+		content   = base64encode("1234")
+	}
+
+	file {
+		file_path = "obsolete.txt"
+		action    = "delete"
+	}
+}
+
+` + "```",
+
+		CreateContext: resourceGitlabRepositoryFilesCreate,
+		ReadContext:   resourceGitlabRepositoryFilesRead,
+		UpdateContext: resourceGitlabRepositoryFilesUpdate,
+		DeleteContext: resourceGitlabRepositoryFilesDelete,
+
+		// the schema matches https://docs.gitlab.com/ee/api/commits.html#create-a-commit-with-multiple-files-and-actions
+		// The file contents are always sent with the `base64` encoding, see the single file resource for details.
+		Schema: map[string]*schema.Schema{
+			"project": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The ID of the project.",
+			},
+			"branch": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The name of the branch to which to commit to.",
+			},
+			"start_branch": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Name of the branch to create the `branch` from, if it doesn't exist yet. Use the `gitlab_repository_branch` resource to also delete a created branch on destroy.",
+			},
+			"author_email": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The email address of the commit author.",
+			},
+			"author_name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The name of the commit author.",
+			},
+			"commit_message": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The commit message.",
+			},
+			"commit_message_templates": commitMessageTemplatesSchema("Templates of the commit messages by action. They take precedence over the templates of the provider. The `{file_path}` placeholder is replaced with all changed files."),
+			"trailers":                 commitTrailersSchema(),
+			"co_authors":               commitCoAuthorsSchema(),
+			"skip_ci":                  commitSkipCISchema(),
+			"file": {
+				Type:        schema.TypeSet,
+				Required:    true,
+				MinItems:    1,
+				Description: "The files to manage in the repository.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"file_path": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "The full path of the file. It must be relative to the root of the project without a leading slash `/`.",
+						},
+						"content": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validateBase64Content,
+							Description:  "The content of the file. It must be base64 encoded. Required unless `action` is `delete`.",
+						},
+						"action": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validation.StringInSlice([]string{string(gitlab.FileCreate), string(gitlab.FileUpdate), string(gitlab.FileDelete)}, false),
+							Description:  "The action to perform for the file. One of `create`, `update` or `delete`. Defaults to `create` for new files and `update` for already managed files.",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func resourceGitlabRepositoryFilesCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*Meta).Client
+	project := d.Get("project").(string)
+	branch := d.Get("branch").(string)
+
+	diags := commitRepositoryFilesActions(ctx, meta.(*Meta), d, commitActionCreate, func() ([]*gitlab.CommitActionOptions, error) {
+		actions := []*gitlab.CommitActionOptions{}
+		for _, f := range d.Get("file").(*schema.Set).List() {
+			file := f.(map[string]interface{})
+			action, err := repositoryFilesActionFor(client, project, branch, file, false)
+			if err != nil {
+				return nil, err
+			}
+			if action != nil {
+				actions = append(actions, action)
+			}
+		}
+		return actions, nil
+	})
+	if diags.HasError() {
+		return diags
+	}
+
+	d.SetId(buildTwoPartID(&project, &branch))
+	return append(diags, resourceGitlabRepositoryFilesRead(ctx, d, meta)...)
+}
+
+func resourceGitlabRepositoryFilesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*Meta).Client
+	project := d.Get("project").(string)
+	branch := d.Get("branch").(string)
+	options := &gitlab.GetFileOptions{
+		Ref: gitlab.String(branch),
+	}
+
+	files := []interface{}{}
+	for _, f := range d.Get("file").(*schema.Set).List() {
+		file := f.(map[string]interface{})
+		filePath := file["file_path"].(string)
+
+		repositoryFile, resp, err := client.RepositoryFiles.GetFile(project, filePath, options)
+		if err != nil && (resp == nil || resp.StatusCode != http.StatusNotFound) {
+			return diag.FromErr(err)
+		}
+
+		// A file which should be deleted but exists again is dropped from the state,
+		// so that the next plan shows it and deletes it again.
+		// A managed file which doesn't exist anymore is dropped for the same reason.
+		if file["action"].(string) == string(gitlab.FileDelete) {
+			if repositoryFile != nil {
+				log.Printf("[WARN] file %s exists again, removing from state", filePath)
+				continue
+			}
+		} else {
+			if repositoryFile == nil {
+				log.Printf("[WARN] file %s not found, removing from state", filePath)
+				continue
+			}
+			file["content"] = repositoryFile.Content
+		}
+		files = append(files, file)
+	}
+
+	if len(files) == 0 {
+		log.Printf("[WARN] none of the files in %s exist as configured, removing from state", d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("project", project)
+	d.Set("branch", branch)
+	d.Set("file", files)
+
+	return nil
+}
+
+func resourceGitlabRepositoryFilesUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*Meta).Client
+	project := d.Get("project").(string)
+	branch := d.Get("branch").(string)
+
+	oldFiles, newFiles := d.GetChange("file")
+	oldFilesByPath := repositoryFilesByPath(oldFiles.(*schema.Set))
+	newFilesByPath := repositoryFilesByPath(newFiles.(*schema.Set))
+
+	diags := commitRepositoryFilesActions(ctx, meta.(*Meta), d, commitActionUpdate, func() ([]*gitlab.CommitActionOptions, error) {
+		actions := []*gitlab.CommitActionOptions{}
+		for filePath, file := range newFilesByPath {
+			oldFile, isManaged := oldFilesByPath[filePath]
+			if isManaged && oldFile["action"].(string) == string(gitlab.FileDelete) {
+				isManaged = false
+			}
+			if isManaged && file["action"].(string) != string(gitlab.FileDelete) && oldFile["content"].(string) == file["content"].(string) {
+				continue
+			}
+
+			action, err := repositoryFilesActionFor(client, project, branch, file, isManaged)
+			if err != nil {
+				return nil, err
+			}
+			if action != nil {
+				actions = append(actions, action)
+			}
+		}
+
+		for filePath, oldFile := range oldFilesByPath {
+			if _, ok := newFilesByPath[filePath]; ok || oldFile["action"].(string) == string(gitlab.FileDelete) {
+				continue
+			}
+			actions = append(actions, &gitlab.CommitActionOptions{
+				Action:   gitlab.FileAction(gitlab.FileDelete),
+				FilePath: gitlab.String(filePath),
+			})
+		}
+		return actions, nil
+	})
+	if diags.HasError() {
+		return diags
+	}
+
+	return append(diags, resourceGitlabRepositoryFilesRead(ctx, d, meta)...)
+}
+
+func resourceGitlabRepositoryFilesDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return commitRepositoryFilesActions(ctx, meta.(*Meta), d, commitActionDelete, func() ([]*gitlab.CommitActionOptions, error) {
+		actions := []*gitlab.CommitActionOptions{}
+		for _, f := range d.Get("file").(*schema.Set).List() {
+			file := f.(map[string]interface{})
+			if file["action"].(string) == string(gitlab.FileDelete) {
+				continue
+			}
+			actions = append(actions, &gitlab.CommitActionOptions{
+				Action:   gitlab.FileAction(gitlab.FileDelete),
+				FilePath: gitlab.String(file["file_path"].(string)),
+			})
+		}
+		return actions, nil
+	})
+}
+
+// repositoryFilesActionFor returns the commit action for the given file block.
+// It returns nil if there is nothing to do for the file, which is the case
+// for a file which should be deleted but doesn't exist.
+func repositoryFilesActionFor(client *gitlab.Client, project, branch string, file map[string]interface{}, isManaged bool) (*gitlab.CommitActionOptions, error) {
+	filePath := file["file_path"].(string)
+	action := gitlab.FileActionValue(file["action"].(string))
+
+	if action == gitlab.FileDelete {
+		options := &gitlab.GetFileOptions{
+			Ref: gitlab.String(branch),
+		}
+		_, resp, err := client.RepositoryFiles.GetFile(project, filePath, options)
+		if err != nil {
+			if resp != nil && resp.StatusCode == http.StatusNotFound {
+				return nil, nil
+			}
+			return nil, err
+		}
+
+		return &gitlab.CommitActionOptions{
+			Action:   gitlab.FileAction(gitlab.FileDelete),
+			FilePath: gitlab.String(filePath),
+		}, nil
+	}
+
+	if file["content"].(string) == "" {
+		return nil, fmt.Errorf("file %s requires a content unless its action is %q", filePath, gitlab.FileDelete)
+	}
+
+	// an already managed file can only be updated, even if it was initially created by this resource.
+	switch {
+	case isManaged:
+		action = gitlab.FileUpdate
+	case action == "":
+		action = gitlab.FileCreate
+	}
+
+	return &gitlab.CommitActionOptions{
+		Action:   gitlab.FileAction(action),
+		FilePath: gitlab.String(filePath),
+		Content:  gitlab.String(file["content"].(string)),
+		Encoding: gitlab.String(encoding),
+	}, nil
+}
+
+// commitRepositoryFilesActions creates a single commit containing all the actions returned by actionsFunc.
+// The actions are determined while the branch is locked, because they depend on the files on the branch.
+// The commit message is rendered for the given commit action of the resource.
+// No commit is created if there are no actions.
+// A pipeline is deferred for the branch after the commit, if configured.
+func commitRepositoryFilesActions(ctx context.Context, meta *Meta, d *schema.ResourceData, commitAction string, actionsFunc func() ([]*gitlab.CommitActionOptions, error)) diag.Diagnostics {
+	lockKey := repositoryBranchLockKey(d.Get("project").(string), d.Get("branch").(string))
+	lockRepositoryBranch(meta, lockKey)
+
+	err := createRepositoryFilesCommit(ctx, meta, d, commitAction, actionsFunc)
+	diags := unlockRepositoryBranch(meta, lockKey)
+	if err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
+	return diags
+}
+
+// createRepositoryFilesCommit creates the commit of commitRepositoryFilesActions while the branch is locked.
+func createRepositoryFilesCommit(ctx context.Context, meta *Meta, d *schema.ResourceData, commitAction string, actionsFunc func() ([]*gitlab.CommitActionOptions, error)) error {
+	actions, err := actionsFunc()
+	if err != nil {
+		return err
+	}
+	if len(actions) == 0 {
+		return nil
+	}
+
+	filePaths := make([]string, 0, len(actions))
+	for _, action := range actions {
+		filePaths = append(filePaths, *action.FilePath)
+	}
+
+	options := &gitlab.CreateCommitOptions{
+		Branch:        gitlab.String(d.Get("branch").(string)),
+		AuthorEmail:   gitlab.String(d.Get("author_email").(string)),
+		AuthorName:    gitlab.String(d.Get("author_name").(string)),
+		CommitMessage: gitlab.String(commitMessage(meta, d, commitAction, filePaths...)),
+		Actions:       actions,
+	}
+
+	err = retryRepositoryWrite(ctx, meta.Config, func() error {
+		// the branch may have been created by a previous attempt, so the start branch is determined again.
+		options.StartBranch = nil
+		startBranch, err := repositoryStartBranch(meta.Client, d.Get("project").(string), *options.Branch, d.Get("start_branch").(string))
+		if err != nil {
+			return err
+		}
+		if startBranch != "" {
+			options.StartBranch = gitlab.String(startBranch)
+		}
+
+		_, _, err = meta.Client.Commits.CreateCommit(d.Get("project").(string), options)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	deferRepositoryPipeline(meta, d, *options.Branch)
+	return nil
+}
+
+func repositoryFilesByPath(files *schema.Set) map[string]map[string]interface{} {
+	filesByPath := make(map[string]map[string]interface{}, files.Len())
+	for _, f := range files.List() {
+		file := f.(map[string]interface{})
+		filesByPath[file["file_path"].(string)] = file
+	}
+	return filesByPath
+}
@@ -0,0 +1,148 @@
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	gitlab "github.com/xanzy/go-gitlab"
+)
+
+func TestAccGitlabRepositoryFiles_createAndUpdate(t *testing.T) {
+	var file gitlab.File
+	rInt := acctest.RandInt()
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		CheckDestroy:      testAccCheckGitlabRepositoryFileDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccGitlabRepositoryFilesConfig(rInt, "bWVvdyBtZW93IG1lb3c="),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("gitlab-repository-files_gitlab_repository_files.this", "file.#", "2"),
+				),
+			},
+			{
+				Config: testAccGitlabRepositoryFilesConfig(rInt, "bWVvdyBtZW93IG1lb3cgbWVvdyBtZW93Cg=="),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("gitlab-repository-files_gitlab_repository_files.this", "file.#", "2"),
+					testAccCheckGitlabRepositoryFilesFile("gitlab-repository-files_gitlab_repository_files.this", "meow.txt", &file),
+					testAccCheckGitlabRepositoryFileAttributes(&file, &testAccGitlabRepositoryFileAttributes{
+						FilePath: "meow.txt",
+						Content:  "bWVvdyBtZW93IG1lb3cgbWVvdyBtZW93Cg==",
+					}),
+				),
+			},
+		},
+	})
+}
+
+func TestAccGitlabRepositoryFiles_actionFor(t *testing.T) {
+	cases := []struct {
+		givenAction    string
+		givenIsManaged bool
+		expectedAction gitlab.FileActionValue
+	}{
+		{
+			givenAction:    "",
+			givenIsManaged: false,
+			expectedAction: gitlab.FileCreate,
+		},
+		{
+			givenAction:    "",
+			givenIsManaged: true,
+			expectedAction: gitlab.FileUpdate,
+		},
+		{
+			givenAction:    "create",
+			givenIsManaged: true,
+			expectedAction: gitlab.FileUpdate,
+		},
+		{
+			givenAction:    "update",
+			givenIsManaged: false,
+			expectedAction: gitlab.FileUpdate,
+		},
+	}
+
+	for _, c := range cases {
+		file := map[string]interface{}{
+			"file_path": "meow.txt",
+			"content":   "bWVvdyBtZW93IG1lb3c=",
+			"action":    c.givenAction,
+		}
+		action, err := repositoryFilesActionFor(nil, "1", "main", file, c.givenIsManaged)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if *action.Action != c.expectedAction {
+			t.Fatalf("action %q (managed: %v) resulted in %q, but expected %q", c.givenAction, c.givenIsManaged, *action.Action, c.expectedAction)
+		}
+	}
+
+	_, err := repositoryFilesActionFor(nil, "1", "main", map[string]interface{}{"file_path": "meow.txt", "content": "", "action": ""}, false)
+	if err == nil {
+		t.Fatalf("expected an error for a file without content")
+	}
+}
+
+func testAccCheckGitlabRepositoryFilesFile(n string, filePath string, file *gitlab.File) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		options := &gitlab.GetFileOptions{
+			Ref: gitlab.String(rs.Primary.Attributes["branch"]),
+		}
+
+		testAccProvider, _ := providerFactories["gitlab-repository-files"]()
+		conn := testAccProvider.Meta().(*Meta).Client
+
+		gotFile, _, err := conn.RepositoryFiles.GetFile(rs.Primary.Attributes["project"], filePath, options)
+		if err != nil {
+			return fmt.Errorf("Cannot get file: %v", err)
+		}
+
+		*file = *gotFile
+		return nil
+	}
+}
+
+func testAccGitlabRepositoryFilesConfig(rInt int, content string) string {
+	return fmt.Sprintf(`
+resource "gitlab_project" "foo" {
+  name = "foo-%d"
+  description = "Terraform acceptance tests"
+
+  default_branch = "main"
+
+  # So that acceptance tests can be run in a gitlab organization
+  # with no billing
+  visibility_level = "public"
+  initialize_with_readme = true
+}
+
+resource "gitlab-repository-files_gitlab_repository_files" "this" {
+  project = "${gitlab_project.foo.id}"
+  branch = "main"
+  author_email = "meow@catnip.com"
+  author_name = "Meow Meowington"
+  commit_message = "feature: add launch codes"
+
+  file {
+    file_path = "meow.txt"
+    content = "%s"
+  }
+
+  file {
+    file_path = "launch/codes.txt"
+    content = "MTIzNA=="
+  }
+}
+	`, rInt, content)
+}
@@ -0,0 +1,382 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+
+	gitlab "github.com/xanzy/go-gitlab"
+)
+
+// onDestroyRevert reverts a patch on destroy.
+const onDestroyRevert = "revert"
+
+func resourceGitlabRepositoryPatch() *schema.Resource {
+	return &schema.Resource{
+		// This description is used by the documentation generator and the language server.
+		Description: `This resource allows you to apply a unified diff as patch to GitLab repository files
+
+Use it to ship a small change to a file which is changed independently by others, e.g. a vendored file,
+instead of managing a full copy of it. The patch is applied to the current content of the files on the branch
+and all changed files are committed at once. Like with GNU patch, a hunk is also applied if lines have been
+added or removed before it and, with the ` + "`fuzz`" + ` factor, if some of its context lines don't match anymore.
+The plan fails if a hunk doesn't apply to the branch. On destroy, the patch is reverse-applied.
+
+` + "```" + `hcl
+resource "gitlab-repository-files_gitlab_repository_patch" "this" {
+	project        = gitlab_project.foo.id
+	branch         = "main"
+	patch          = file("patches/vendor-timeout.diff")
+	author_email   = "meow@catnip.com"
+	author_name    = "Meow Meowington"
+	commit_message = "fix: increase timeout of vendored client"
+}
+` + "```",
+
+		CreateContext: resourceGitlabRepositoryPatchCreate,
+		ReadContext:   resourceGitlabRepositoryPatchRead,
+		UpdateContext: resourceGitlabRepositoryPatchUpdate,
+		DeleteContext: resourceGitlabRepositoryPatchDelete,
+		CustomizeDiff: resourceGitlabRepositoryPatchCustomizeDiff,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(60 * time.Minute),
+			Update: schema.DefaultTimeout(60 * time.Minute),
+			Delete: schema.DefaultTimeout(60 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"project": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The ID of the project.",
+			},
+			"branch": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The name of the branch to which to commit to.",
+			},
+			"patch": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validateUnifiedDiff,
+				Description:  "The unified diff to apply, e.g. created with `git diff` or `diff -u`. Paths are relative to the root of the project, the `a/` and `b/` prefixes of git are removed. A changed patch is applied after reverting the previous one.",
+			},
+			"fuzz": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      2,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "The maximum number of leading and trailing context lines of a hunk which may be ignored if the hunk doesn't apply otherwise. Set it to `0` to require all context lines to match.",
+			},
+			"author_email": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The email address of the commit author.",
+			},
+			"author_name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The name of the commit author.",
+			},
+			"commit_message": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The commit message.",
+			},
+			"commit_message_templates": commitMessageTemplatesSchema("Templates of the commit messages by action. They take precedence over the templates of the provider. The `{file_path}` placeholder is replaced with all patched files."),
+			"trailers":                 commitTrailersSchema(),
+			"co_authors":               commitCoAuthorsSchema(),
+			"skip_ci":                  commitSkipCISchema(),
+			"on_destroy": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      onDestroyRevert,
+				ValidateFunc: validation.StringInSlice([]string{onDestroyRevert, onDestroyAbandon}, false),
+				Description:  "What happens to the patched files on destroy. Either `revert` to reverse-apply the patch or `abandon` to leave them as they are.",
+			},
+			"files": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The paths of the files changed by the patch.",
+			},
+		},
+	}
+}
+
+func resourceGitlabRepositoryPatchCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	patches, err := parseUnifiedDiff(d.Get("patch").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	diags := commitRepositoryPatches(ctx, meta.(*Meta), d, commitActionCreate, patches)
+	if diags.HasError() {
+		return diags
+	}
+
+	d.SetId(buildRepositoryPatchID(d.Get("project").(string), d.Get("branch").(string), d.Get("patch").(string)))
+	return append(diags, resourceGitlabRepositoryPatchRead(ctx, d, meta)...)
+}
+
+func resourceGitlabRepositoryPatchRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	patches, err := parseUnifiedDiff(d.Get("patch").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	// the patch is only still applied if it can be reverted.
+	_, err = applyRepositoryPatches(meta.(*Meta).Client, d.Get("project").(string), d.Get("branch").(string), reverseFilePatches(patches), d.Get("fuzz").(int))
+	if err != nil {
+		if _, ok := err.(*patchConflictError); ok {
+			log.Printf("[WARN] patch %s isn't applied anymore, removing from state: %v", d.Id(), err)
+			d.SetId("")
+			return nil
+		}
+		return diag.Errorf("failed to read files of patch %s: %v", d.Id(), err)
+	}
+
+	files := make([]string, len(patches))
+	for i, patch := range patches {
+		files[i] = patch.Path()
+	}
+	d.Set("files", files)
+	return nil
+}
+
+func resourceGitlabRepositoryPatchUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if d.HasChange("patch") {
+		patches, err := changedRepositoryPatches(d.GetChange("patch"))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		diags = commitRepositoryPatches(ctx, meta.(*Meta), d, commitActionUpdate, patches)
+		if diags.HasError() {
+			return diags
+		}
+	}
+	return append(diags, resourceGitlabRepositoryPatchRead(ctx, d, meta)...)
+}
+
+func resourceGitlabRepositoryPatchDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if d.Get("on_destroy").(string) == onDestroyAbandon {
+		log.Printf("[DEBUG] abandoning patch %s, the patched files are kept in the repository", d.Id())
+		return nil
+	}
+
+	patches, err := parseUnifiedDiff(d.Get("patch").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	return commitRepositoryPatches(ctx, meta.(*Meta), d, commitActionDelete, reverseFilePatches(patches))
+}
+
+// resourceGitlabRepositoryPatchCustomizeDiff fails the plan if the patch doesn't apply to the branch.
+// The check is skipped if the branch doesn't exist yet, e.g. because it's created in the same apply.
+func resourceGitlabRepositoryPatchCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() != "" && !d.HasChange("patch") && !d.HasChange("fuzz") {
+		return nil
+	}
+	if !d.NewValueKnown("project") || !d.NewValueKnown("branch") || !d.NewValueKnown("patch") {
+		return nil
+	}
+
+	client := meta.(*Meta).Client
+	project := d.Get("project").(string)
+	branch := d.Get("branch").(string)
+
+	exists, err := repositoryBranchExists(client, project, branch)
+	if err != nil {
+		return fmt.Errorf("failed to get branch %s: %v", branch, err)
+	}
+	if !exists {
+		log.Printf("[DEBUG] skipping check of patch, because branch %s doesn't exist yet", branch)
+		return nil
+	}
+
+	oldPatch, newPatch := d.GetChange("patch")
+	if d.Id() == "" {
+		oldPatch = ""
+	}
+	patches, err := changedRepositoryPatches(oldPatch, newPatch)
+	if err != nil {
+		return err
+	}
+	if _, err := applyRepositoryPatches(client, project, branch, patches, d.Get("fuzz").(int)); err != nil {
+		return fmt.Errorf("patch doesn't apply to branch %s: %v", branch, err)
+	}
+	return nil
+}
+
+// changedRepositoryPatches returns the file patches to apply to change from the old to the new patch,
+// which are the reversed file patches of the old patch followed by the file patches of the new patch.
+func changedRepositoryPatches(oldPatch, newPatch interface{}) ([]*filePatch, error) {
+	var patches []*filePatch
+	if oldPatch.(string) != "" {
+		oldPatches, err := parseUnifiedDiff(oldPatch.(string))
+		if err != nil {
+			return nil, err
+		}
+		patches = reverseFilePatches(oldPatches)
+	}
+
+	newPatches, err := parseUnifiedDiff(newPatch.(string))
+	if err != nil {
+		return nil, err
+	}
+	return append(patches, newPatches...), nil
+}
+
+// reverseFilePatches returns the file patches which revert the given file patches.
+func reverseFilePatches(patches []*filePatch) []*filePatch {
+	reversed := make([]*filePatch, len(patches))
+	for i, patch := range patches {
+		reversed[len(patches)-1-i] = patch.Reverse()
+	}
+	return reversed
+}
+
+// commitRepositoryPatches applies the file patches to the files on the branch and commits all changed files at once.
+// The files are fetched again on every attempt, so that the patches are always applied to their latest content.
+func commitRepositoryPatches(ctx context.Context, meta *Meta, d *schema.ResourceData, action string, patches []*filePatch) diag.Diagnostics {
+	client := meta.Client
+	project := d.Get("project").(string)
+	branch := d.Get("branch").(string)
+
+	lockKey := repositoryBranchLockKey(project, branch)
+	lockRepositoryBranch(meta, lockKey)
+
+	var committed bool
+	err := retryRepositoryWrite(ctx, meta.Config, func() error {
+		actions, err := applyRepositoryPatches(client, project, branch, patches, d.Get("fuzz").(int))
+		if err != nil {
+			return err
+		}
+		if len(actions) == 0 {
+			log.Printf("[DEBUG] patch doesn't change any file, skipping commit")
+			committed = false
+			return nil
+		}
+
+		filePaths := make([]string, len(actions))
+		for i, action := range actions {
+			filePaths[i] = *action.FilePath
+		}
+
+		committed = true
+		return commitRepositoryFile(client, d, branch, "", commitMessage(meta, d, action, filePaths...), actions)
+	})
+	if err == nil && committed {
+		deferRepositoryPipeline(meta, d, branch)
+	}
+	diags := unlockRepositoryBranch(meta, lockKey)
+	if err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
+	return diags
+}
+
+// patchedRepositoryFile is a file of the branch, while file patches are applied to it.
+type patchedRepositoryFile struct {
+	path            string
+	originalExists  bool
+	originalContent string
+	lastCommitID    string
+	exists          bool
+	content         string
+}
+
+// patchConflictError is returned if a file patch doesn't apply to a file.
+type patchConflictError struct {
+	err error
+}
+
+func (e *patchConflictError) Error() string {
+	return e.err.Error()
+}
+
+// applyRepositoryPatches applies the file patches in order to the files on the branch
+// and returns the commit actions for all files which have been changed by them.
+// Errors of the API aren't wrapped, so that they can still be retried.
+func applyRepositoryPatches(client *gitlab.Client, project, branch string, patches []*filePatch, fuzz int) ([]*gitlab.CommitActionOptions, error) {
+	var files []*patchedRepositoryFile
+	filesByPath := map[string]*patchedRepositoryFile{}
+
+	for _, patch := range patches {
+		file, ok := filesByPath[patch.Path()]
+		if !ok {
+			file = &patchedRepositoryFile{path: patch.Path()}
+			repositoryFile, resp, err := client.RepositoryFiles.GetFile(project, file.path, &gitlab.GetFileOptions{Ref: gitlab.String(branch)})
+			if err != nil && (resp == nil || resp.StatusCode != http.StatusNotFound) {
+				return nil, err
+			}
+			if err == nil {
+				content, err := base64.StdEncoding.DecodeString(repositoryFile.Content)
+				if err != nil {
+					return nil, fmt.Errorf("failed to decode content of repository file %s: %v", file.path, err)
+				}
+				file.originalExists, file.originalContent, file.lastCommitID = true, string(content), repositoryFile.LastCommitID
+			}
+			file.exists, file.content = file.originalExists, file.originalContent
+
+			files = append(files, file)
+			filesByPath[file.path] = file
+		}
+
+		switch {
+		case patch.OldPath == devNull && file.exists:
+			return nil, &patchConflictError{fmt.Errorf("file %s is created by the patch, but does already exist on branch %s", file.path, branch)}
+		case patch.OldPath != devNull && !file.exists:
+			return nil, &patchConflictError{fmt.Errorf("file %s doesn't exist on branch %s", file.path, branch)}
+		}
+
+		content, err := patch.Apply(file.content, fuzz)
+		if err != nil {
+			return nil, &patchConflictError{err}
+		}
+		file.exists, file.content = patch.NewPath != devNull, content
+	}
+
+	var actions []*gitlab.CommitActionOptions
+	for _, file := range files {
+		action := &gitlab.CommitActionOptions{
+			FilePath: gitlab.String(file.path),
+			Content:  gitlab.String(base64.StdEncoding.EncodeToString([]byte(file.content))),
+			Encoding: gitlab.String(encoding),
+		}
+		switch {
+		case file.originalExists && !file.exists:
+			action = &gitlab.CommitActionOptions{
+				Action:       gitlab.FileAction(gitlab.FileDelete),
+				FilePath:     gitlab.String(file.path),
+				LastCommitID: gitlab.String(file.lastCommitID),
+			}
+		case !file.originalExists && file.exists:
+			action.Action = gitlab.FileAction(gitlab.FileCreate)
+		case file.exists && file.content != file.originalContent:
+			action.Action = gitlab.FileAction(gitlab.FileUpdate)
+			action.LastCommitID = gitlab.String(file.lastCommitID)
+		default:
+			continue
+		}
+		actions = append(actions, action)
+	}
+	return actions, nil
+}
+
+func validateUnifiedDiff(v interface{}, k string) (we []string, errors []error) {
+	if _, err := parseUnifiedDiff(v.(string)); err != nil {
+		errors = append(errors, fmt.Errorf("%q must be a unified diff: %v", k, err))
+	}
+	return
+}
@@ -0,0 +1,131 @@
+package provider
+
+import (
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	gitlab "github.com/xanzy/go-gitlab"
+)
+
+const testAccGitlabRepositoryPatchOriginalContent = "host = gitlab.com\ntimeout = 10\nretries = 3\n"
+
+func TestAccGitlabRepositoryPatch_basic(t *testing.T) {
+	rInt := acctest.RandInt()
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		CheckDestroy:      testAccCheckGitlabRepositoryFileDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccGitlabRepositoryPatchConfig(rInt, "timeout = 10", "timeout = 30"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("gitlab-repository-files_gitlab_repository_patch.this", "files.#", "1"),
+					resource.TestCheckResourceAttr("gitlab-repository-files_gitlab_repository_patch.this", "files.0", "client.conf"),
+					testAccCheckGitlabRepositoryPatchedFile("gitlab-repository-files_gitlab_repository_file.this", "host = gitlab.com\ntimeout = 30\nretries = 3\n"),
+				),
+			},
+			// the changed patch is applied instead of the previous one.
+			{
+				Config: testAccGitlabRepositoryPatchConfig(rInt, "timeout = 10", "timeout = 60"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckGitlabRepositoryPatchedFile("gitlab-repository-files_gitlab_repository_file.this", "host = gitlab.com\ntimeout = 60\nretries = 3\n"),
+				),
+			},
+			// a hunk which doesn't match the file fails the plan.
+			{
+				Config:      testAccGitlabRepositoryPatchConfig(rInt, "timeout = 20", "timeout = 90"),
+				ExpectError: regexp.MustCompile("patch doesn't apply to branch main"),
+			},
+			// the patch is reverted on destroy, while the file itself is kept.
+			{
+				Config: testAccGitlabRepositoryPatchFileConfig(rInt),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckGitlabRepositoryPatchedFile("gitlab-repository-files_gitlab_repository_file.this", testAccGitlabRepositoryPatchOriginalContent),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckGitlabRepositoryPatchedFile(n string, expectedContent string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		options := &gitlab.GetFileOptions{
+			Ref: gitlab.String(rs.Primary.Attributes["branch"]),
+		}
+
+		testAccProvider, _ := providerFactories["gitlab-repository-files"]()
+		conn := testAccProvider.Meta().(*Meta).Client
+
+		gotFile, _, err := conn.RepositoryFiles.GetFile(rs.Primary.Attributes["project"], rs.Primary.Attributes["file_path"], options)
+		if err != nil {
+			return fmt.Errorf("Cannot get file: %v", err)
+		}
+		content, err := base64.StdEncoding.DecodeString(gotFile.Content)
+		if err != nil {
+			return fmt.Errorf("Cannot decode file: %v", err)
+		}
+		if string(content) != expectedContent {
+			return fmt.Errorf("got content %q of file %s; want %q", content, gotFile.FilePath, expectedContent)
+		}
+		return nil
+	}
+}
+
+func testAccGitlabRepositoryPatchFileConfig(rInt int) string {
+	return fmt.Sprintf(`
+resource "gitlab_project" "foo" {
+  name = "foo-%d"
+  description = "Terraform acceptance tests"
+
+  # So that acceptance tests can be run in a gitlab organization
+  # with no billing
+  visibility_level = "public"
+}
+
+resource "gitlab-repository-files_gitlab_repository_file" "this" {
+  project = "${gitlab_project.foo.id}"
+  file_path = "client.conf"
+  branch = "main"
+  content = %q
+  author_email = "meow@catnip.com"
+  author_name = "Meow Meowington"
+  commit_message = "feature: add client config"
+
+  lifecycle {
+    ignore_changes = [content]
+  }
+}
+	`, rInt, testAccGitlabRepositoryPatchOriginalContent)
+}
+
+func testAccGitlabRepositoryPatchConfig(rInt int, removedLine, addedLine string) string {
+	return testAccGitlabRepositoryPatchFileConfig(rInt) + fmt.Sprintf(`
+resource "gitlab-repository-files_gitlab_repository_patch" "this" {
+  project = gitlab-repository-files_gitlab_repository_file.this.project
+  branch = gitlab-repository-files_gitlab_repository_file.this.branch
+  patch = <<-EOT
+    --- a/client.conf
+    +++ b/client.conf
+    @@ -1,3 +1,3 @@
+     host = gitlab.com
+    -%s
+    +%s
+     retries = 3
+  EOT
+  author_email = "meow@catnip.com"
+  author_name = "Meow Meowington"
+  commit_message = "fix: change timeout"
+}
+	`, removedLine, addedLine)
+}
@@ -0,0 +1,97 @@
+package provider
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	gitlab "github.com/xanzy/go-gitlab"
+)
+
+// retryRepositoryWrite calls the given write function until it succeeds, fails with an error which isn't worth
+// a retry or the configured number of retries is exhausted.
+// The write function must re-fetch any state of the repository it depends on, e.g. the last commit id of a file,
+// because it has likely changed if a retry is necessary.
+func retryRepositoryWrite(ctx context.Context, config *Config, write func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := write()
+		if err == nil {
+			return nil
+		}
+
+		retryable, retryAfter := isRetryableError(err)
+		if !retryable || attempt >= config.Retries {
+			return err
+		}
+
+		wait := retryWait(attempt, retryAfter, config.MaxRetryWait)
+		log.Printf("[WARN] retrying write to repository in %s (retry %d of %d) after error: %v", wait, attempt+1, config.Retries, err)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+	}
+}
+
+// isRetryableError returns true if the given error is caused by a concurrent change to the branch
+// or is a transient API error. In addition, it returns how long GitLab asked to wait before a retry, if at all.
+func isRetryableError(err error) (bool, time.Duration) {
+	errResponse, ok := err.(*gitlab.ErrorResponse)
+	if !ok || errResponse.Response == nil {
+		return false, 0
+	}
+
+	retryAfter := parseRetryAfter(errResponse.Response.Header.Get("Retry-After"))
+
+	switch statusCode := errResponse.Response.StatusCode; {
+	case statusCode == http.StatusConflict, statusCode == http.StatusTooManyRequests, statusCode >= http.StatusInternalServerError:
+		return true, retryAfter
+	case statusCode == http.StatusBadRequest:
+		// GitLab responds with a 400 if the branch has been moved while the change was being processed.
+		return isBranchMovedMessage(errResponse.Message), retryAfter
+	default:
+		return false, 0
+	}
+}
+
+func isBranchMovedMessage(message string) bool {
+	return strings.Contains(message, "Please refresh and try again") || strings.Contains(message, "Could not update")
+}
+
+// parseRetryAfter parses the value of a `Retry-After` header, which is either
+// the number of seconds to wait or the date after which to retry.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait
+		}
+	}
+
+	return 0
+}
+
+// retryWait returns the time to wait before the given retry attempt.
+// It backs off exponentially starting at one second, unless GitLab asked for a specific wait time.
+func retryWait(attempt int, retryAfter time.Duration, maxWait time.Duration) time.Duration {
+	wait := retryAfter
+	if wait == 0 {
+		wait = time.Second << uint(attempt)
+	}
+	if wait > maxWait || wait <= 0 {
+		wait = maxWait
+	}
+	return wait
+}
@@ -0,0 +1,117 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	gitlab "github.com/xanzy/go-gitlab"
+)
+
+func testErrorResponse(statusCode int, message string, header http.Header) *gitlab.ErrorResponse {
+	return &gitlab.ErrorResponse{
+		Response: &http.Response{StatusCode: statusCode, Header: header},
+		Message:  message,
+	}
+}
+
+func TestIsRetryableError(t *testing.T) {
+	cases := []struct {
+		givenErr           error
+		expectedRetryable  bool
+		expectedRetryAfter time.Duration
+	}{
+		{
+			givenErr:          testErrorResponse(http.StatusConflict, "", http.Header{}),
+			expectedRetryable: true,
+		},
+		{
+			givenErr:           testErrorResponse(http.StatusTooManyRequests, "", http.Header{"Retry-After": []string{"7"}}),
+			expectedRetryable:  true,
+			expectedRetryAfter: 7 * time.Second,
+		},
+		{
+			givenErr:          testErrorResponse(http.StatusBadGateway, "", http.Header{}),
+			expectedRetryable: true,
+		},
+		{
+			givenErr:          testErrorResponse(http.StatusBadRequest, "{message: 9:Could not update refs/heads/main. Please refresh and try again.}", http.Header{}),
+			expectedRetryable: true,
+		},
+		{
+			givenErr:          testErrorResponse(http.StatusBadRequest, "{message: You are attempting to update a file that has changed since you started editing it.}", http.Header{}),
+			expectedRetryable: false,
+		},
+		{
+			givenErr:          testErrorResponse(http.StatusForbidden, "", http.Header{}),
+			expectedRetryable: false,
+		},
+		{
+			givenErr:          fmt.Errorf("no api error"),
+			expectedRetryable: false,
+		},
+	}
+
+	for _, c := range cases {
+		retryable, retryAfter := isRetryableError(c.givenErr)
+		if retryable != c.expectedRetryable || retryAfter != c.expectedRetryAfter {
+			t.Fatalf("got (%v, %s) for error '%v'; want (%v, %s)", retryable, retryAfter, c.givenErr, c.expectedRetryable, c.expectedRetryAfter)
+		}
+	}
+}
+
+func TestRetryWait(t *testing.T) {
+	cases := []struct {
+		givenAttempt    int
+		givenRetryAfter time.Duration
+		expectedWait    time.Duration
+	}{
+		{givenAttempt: 0, expectedWait: 1 * time.Second},
+		{givenAttempt: 2, expectedWait: 4 * time.Second},
+		{givenAttempt: 10, expectedWait: 30 * time.Second},
+		{givenAttempt: 0, givenRetryAfter: 5 * time.Second, expectedWait: 5 * time.Second},
+		{givenAttempt: 0, givenRetryAfter: time.Hour, expectedWait: 30 * time.Second},
+	}
+
+	for _, c := range cases {
+		if wait := retryWait(c.givenAttempt, c.givenRetryAfter, 30*time.Second); wait != c.expectedWait {
+			t.Fatalf("got wait %s for attempt %d with retry after %s; want %s", wait, c.givenAttempt, c.givenRetryAfter, c.expectedWait)
+		}
+	}
+}
+
+func TestRetryRepositoryWrite(t *testing.T) {
+	config := &Config{Retries: 2, MaxRetryWait: time.Millisecond}
+
+	attempts := 0
+	err := retryRepositoryWrite(context.Background(), config, func() error {
+		attempts++
+		return testErrorResponse(http.StatusConflict, "", http.Header{})
+	})
+	if err == nil || attempts != 3 {
+		t.Fatalf("expected an error after 3 attempts, got %v after %d attempts", err, attempts)
+	}
+
+	attempts = 0
+	err = retryRepositoryWrite(context.Background(), config, func() error {
+		attempts++
+		if attempts < 2 {
+			return testErrorResponse(http.StatusServiceUnavailable, "", http.Header{})
+		}
+		return nil
+	})
+	if err != nil || attempts != 2 {
+		t.Fatalf("expected success after 2 attempts, got %v after %d attempts", err, attempts)
+	}
+
+	attempts = 0
+	err = retryRepositoryWrite(context.Background(), config, func() error {
+		attempts++
+		return testErrorResponse(http.StatusForbidden, "", http.Header{})
+	})
+	if err == nil || attempts != 1 {
+		t.Fatalf("expected an error after 1 attempt, got %v after %d attempts", err, attempts)
+	}
+}
@@ -0,0 +1,621 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	structuredFormatJSON = "json"
+	structuredFormatYAML = "yaml"
+	structuredFormatTOML = "toml"
+)
+
+var structuredFormats = []string{structuredFormatJSON, structuredFormatYAML, structuredFormatTOML}
+
+// orderedObject is a decoded object which keeps the order of its keys,
+// so that a patched file is written with its keys in the original order.
+type orderedObject []orderedField
+
+type orderedField struct {
+	Key   string
+	Value interface{}
+}
+
+// structuredDocument is the parsed content of a structured file, which can be patched
+// and written back while keeping its formatting as much as possible.
+// Values are exchanged as decoded JSON, i.e. orderedObject, []interface{}, string,
+// json.Number, bool or nil.
+type structuredDocument interface {
+	Get(keys []string) (interface{}, bool, error)
+	Set(keys []string, value interface{}) error
+	Remove(keys []string) error
+	String() (string, error)
+}
+
+// structuredFileFormat returns the format of a structured file by its extension.
+func structuredFileFormat(filePath string) string {
+	switch strings.ToLower(path.Ext(filePath)) {
+	case ".json":
+		return structuredFormatJSON
+	case ".yml", ".yaml":
+		return structuredFormatYAML
+	case ".toml":
+		return structuredFormatTOML
+	}
+	return ""
+}
+
+// utf8BOM is the byte order mark, which some editors write at the start of UTF-8 files.
+const utf8BOM = "\ufeff"
+
+// bomDocument is a structured document of a file starting with a byte order mark,
+// which is only parsed without it and written with it again.
+type bomDocument struct {
+	structuredDocument
+}
+
+func (d *bomDocument) String() (string, error) {
+	content, err := d.structuredDocument.String()
+	return utf8BOM + content, err
+}
+
+func parseStructuredDocument(format, content string) (structuredDocument, error) {
+	if strings.HasPrefix(content, utf8BOM) {
+		document, err := parseStructuredDocument(format, strings.TrimPrefix(content, utf8BOM))
+		if err != nil {
+			return nil, err
+		}
+		return &bomDocument{document}, nil
+	}
+
+	switch format {
+	case structuredFormatJSON:
+		return parseJSONDocument(content)
+	case structuredFormatYAML:
+		return parseYAMLDocument(content)
+	case structuredFormatTOML:
+		return parseTOMLDocument(content)
+	}
+	return nil, fmt.Errorf("unsupported format %q, expected one of %s", format, strings.Join(structuredFormats, ", "))
+}
+
+// parseDocumentPath returns the keys of a JSON pointer like `/scripts/build` or of a dotted path like `scripts.build`.
+// Keys of arrays are their indices.
+func parseDocumentPath(documentPath string) ([]string, error) {
+	if documentPath == "" {
+		return nil, fmt.Errorf("document path must not be empty")
+	}
+
+	if strings.HasPrefix(documentPath, "/") {
+		keys := strings.Split(documentPath[1:], "/")
+		unescaper := strings.NewReplacer("~1", "/", "~0", "~")
+		for i, key := range keys {
+			keys[i] = unescaper.Replace(key)
+		}
+		return keys, nil
+	}
+
+	keys := strings.Split(documentPath, ".")
+	for _, key := range keys {
+		if key == "" {
+			return nil, fmt.Errorf("document path %q contains an empty key, use a JSON pointer for keys with dots", documentPath)
+		}
+	}
+	return keys, nil
+}
+
+// decodeOrderedJSON decodes the given JSON while keeping the order of the keys of objects.
+func decodeOrderedJSON(data string) (interface{}, error) {
+	decoder := json.NewDecoder(strings.NewReader(data))
+	decoder.UseNumber()
+
+	value, err := decodeOrderedJSONValue(decoder)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+	return value, nil
+}
+
+func decodeOrderedJSONValue(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		object := orderedObject{}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrderedJSONValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			object = append(object, orderedField{Key: key.(string), Value: value})
+		}
+		_, err := decoder.Token()
+		return object, err
+	case json.Delim('['):
+		array := []interface{}{}
+		for decoder.More() {
+			value, err := decodeOrderedJSONValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		_, err := decoder.Token()
+		return array, err
+	}
+	return token, nil
+}
+
+// encodeOrderedJSON encodes the given value as JSON. Without an indent, the JSON is compact.
+func encodeOrderedJSON(value interface{}, indent string) string {
+	var buf strings.Builder
+	writeOrderedJSON(&buf, value, indent, 0)
+	return buf.String()
+}
+
+func writeOrderedJSON(buf *strings.Builder, value interface{}, indent string, level int) {
+	newline, separator := "", ":"
+	if indent != "" {
+		newline, separator = "\n", ": "
+	}
+	writeIndent := func(level int) {
+		buf.WriteString(newline + strings.Repeat(indent, level))
+	}
+
+	switch v := value.(type) {
+	case orderedObject:
+		if len(v) == 0 {
+			buf.WriteString("{}")
+			return
+		}
+		buf.WriteString("{")
+		for i, field := range v {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			writeIndent(level + 1)
+			buf.WriteString(marshalJSONString(field.Key) + separator)
+			writeOrderedJSON(buf, field.Value, indent, level+1)
+		}
+		writeIndent(level)
+		buf.WriteString("}")
+	case []interface{}:
+		if len(v) == 0 {
+			buf.WriteString("[]")
+			return
+		}
+		buf.WriteString("[")
+		for i, element := range v {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			writeIndent(level + 1)
+			writeOrderedJSON(buf, element, indent, level+1)
+		}
+		writeIndent(level)
+		buf.WriteString("]")
+	case string:
+		buf.WriteString(marshalJSONString(v))
+	case json.Number:
+		buf.WriteString(v.String())
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case nil:
+		buf.WriteString("null")
+	default:
+		panic(fmt.Sprintf("unexpected JSON value of type %T", value))
+	}
+}
+
+// marshalJSONString returns the given string as JSON string without escaping HTML characters.
+func marshalJSONString(s string) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	// encoding a string can't fail.
+	_ = encoder.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// getOrderedValue returns the value at the keys in the decoded JSON value.
+func getOrderedValue(node interface{}, keys []string) (interface{}, bool) {
+	if len(keys) == 0 {
+		return node, true
+	}
+
+	switch n := node.(type) {
+	case orderedObject:
+		for _, field := range n {
+			if field.Key == keys[0] {
+				return getOrderedValue(field.Value, keys[1:])
+			}
+		}
+	case []interface{}:
+		if index, err := strconv.Atoi(keys[0]); err == nil && index >= 0 && index < len(n) {
+			return getOrderedValue(n[index], keys[1:])
+		}
+	}
+	return nil, false
+}
+
+// setOrderedValue returns the decoded JSON value with the value at the keys set to the given value.
+// Missing objects on the way are created, an index of `-` or the length of an array appends to it.
+func setOrderedValue(node interface{}, keys []string, value interface{}) (interface{}, error) {
+	if len(keys) == 0 {
+		return value, nil
+	}
+
+	switch n := node.(type) {
+	case orderedObject:
+		for i, field := range n {
+			if field.Key == keys[0] {
+				fieldValue, err := setOrderedValue(field.Value, keys[1:], value)
+				n[i].Value = fieldValue
+				return n, err
+			}
+		}
+		fieldValue, err := setOrderedValue(nil, keys[1:], value)
+		return append(n, orderedField{Key: keys[0], Value: fieldValue}), err
+	case []interface{}:
+		index, err := strconv.Atoi(keys[0])
+		if keys[0] == "-" {
+			index, err = len(n), nil
+		}
+		if err != nil || index < 0 || index > len(n) {
+			return nil, fmt.Errorf("invalid index %q of an array with %d elements", keys[0], len(n))
+		}
+		if index == len(n) {
+			n = append(n, nil)
+		}
+		element, err := setOrderedValue(n[index], keys[1:], value)
+		n[index] = element
+		return n, err
+	case nil:
+		return setOrderedValue(orderedObject{}, keys, value)
+	}
+	return nil, fmt.Errorf("key %q can't be set, because its parent is neither an object nor an array", keys[0])
+}
+
+// nestedOrderedValue returns the value nested in objects of the keys, e.g. `{"a": {"b": value}}` for the keys `a` and `b`.
+func nestedOrderedValue(keys []string, value interface{}) interface{} {
+	for i := len(keys) - 1; i >= 0; i-- {
+		value = orderedObject{{Key: keys[i], Value: value}}
+	}
+	return value
+}
+
+// removeOrderedValue returns the decoded JSON value without the value at the keys.
+func removeOrderedValue(node interface{}, keys []string) interface{} {
+	switch n := node.(type) {
+	case orderedObject:
+		for i, field := range n {
+			if field.Key != keys[0] {
+				continue
+			}
+			if len(keys) == 1 {
+				return append(n[:i:i], n[i+1:]...)
+			}
+			n[i].Value = removeOrderedValue(field.Value, keys[1:])
+			return n
+		}
+	case []interface{}:
+		index, err := strconv.Atoi(keys[0])
+		if err != nil || index < 0 || index >= len(n) {
+			return n
+		}
+		if len(keys) == 1 {
+			return append(n[:index:index], n[index+1:]...)
+		}
+		n[index] = removeOrderedValue(n[index], keys[1:])
+		return n
+	}
+	return node
+}
+
+// jsonDocument is a JSON file, which is patched in place, so that the formatting of the values
+// which aren't changed is kept. New values are written with the indentation and the line ending of the file.
+// They are written compact into a file without any indented line, e.g. minified JSON, and into inline objects and arrays.
+type jsonDocument struct {
+	content string
+	indent  string
+	newline string
+}
+
+// jsonNode is a value of a JSON document with its position in the content.
+// Only objects have fields and only arrays have elements.
+type jsonNode struct {
+	start    int
+	end      int
+	kind     byte
+	fields   []jsonField
+	elements []*jsonNode
+}
+
+// jsonField is a field of an object, which starts at its key.
+type jsonField struct {
+	key      string
+	keyStart int
+	keyEnd   int
+	value    *jsonNode
+}
+
+func parseJSONDocument(content string) (*jsonDocument, error) {
+	if _, err := decodeOrderedJSON(content); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %v", err)
+	}
+
+	return &jsonDocument{
+		content: content,
+		indent:  detectIndent(content, ""),
+		newline: detectNewline(content),
+	}, nil
+}
+
+func (d *jsonDocument) Get(keys []string) (interface{}, bool, error) {
+	root, err := decodeOrderedJSON(d.content)
+	if err != nil {
+		return nil, false, err
+	}
+	value, ok := getOrderedValue(root, keys)
+	return value, ok, nil
+}
+
+func (d *jsonDocument) Set(keys []string, value interface{}) error {
+	node := d.root()
+	for i, key := range keys {
+		switch node.kind {
+		case '{':
+			field := node.field(key)
+			if field == nil {
+				return d.addEntry(node, key, nestedOrderedValue(keys[i+1:], value))
+			}
+			node = field.value
+		case '[':
+			index, err := strconv.Atoi(key)
+			if key == "-" {
+				index, err = len(node.elements), nil
+			}
+			if err != nil || index < 0 || index > len(node.elements) {
+				return fmt.Errorf("invalid index %q of an array with %d elements", key, len(node.elements))
+			}
+			if index == len(node.elements) {
+				return d.addEntry(node, "", nestedOrderedValue(keys[i+1:], value))
+			}
+			node = node.elements[index]
+		default:
+			if d.content[node.start:node.end] != "null" {
+				return fmt.Errorf("key %q can't be set, because its parent is neither an object nor an array", key)
+			}
+			return d.replace(node.start, node.end, d.render(nestedOrderedValue(keys[i:], value), d.lineIndent(node.start), true))
+		}
+	}
+	return d.replace(node.start, node.end, d.render(value, d.lineIndent(node.start), true))
+}
+
+func (d *jsonDocument) Remove(keys []string) error {
+	node := d.root()
+	for i, key := range keys {
+		index := -1
+		switch node.kind {
+		case '{':
+			for j, field := range node.fields {
+				if field.key == key {
+					index = j
+					break
+				}
+			}
+		case '[':
+			if j, err := strconv.Atoi(key); err == nil && j >= 0 && j < len(node.elements) {
+				index = j
+			}
+		}
+		if index < 0 {
+			return nil
+		}
+		if i < len(keys)-1 {
+			if node.kind == '{' {
+				node = node.fields[index].value
+			} else {
+				node = node.elements[index]
+			}
+			continue
+		}
+
+		// the entry is removed together with the separator before it or, if it's the first one, after it.
+		starts, ends := node.entries()
+		switch {
+		case index > 0:
+			return d.replace(ends[index-1], ends[index], "")
+		case len(starts) > 1:
+			return d.replace(starts[0], starts[1], "")
+		default:
+			return d.replace(node.start+1, node.end-1, "")
+		}
+	}
+	return nil
+}
+
+func (d *jsonDocument) String() (string, error) {
+	return d.content, nil
+}
+
+// addEntry adds the field with the key to the object or the value to the array. The entry is appended
+// after the last one with the same separator as the one in front of the last entry.
+func (d *jsonDocument) addEntry(node *jsonNode, key string, value interface{}) error {
+	var entry interface{} = []interface{}{value}
+	if node.kind == '{' {
+		entry = orderedObject{{Key: key, Value: value}}
+	}
+
+	starts, ends := node.entries()
+	if len(starts) == 0 {
+		return d.replace(node.start, node.end, d.render(entry, d.lineIndent(node.start), true))
+	}
+
+	last := len(starts) - 1
+	separator := d.content[node.start+1 : starts[0]]
+	if last > 0 {
+		separator = d.content[ends[last-1]:starts[last]]
+		separator = separator[strings.Index(separator, ",")+1:]
+	}
+	lastStart, lastEnd := starts[last], ends[last]
+	multiline := strings.Contains(separator, "\n")
+	s := d.render(value, d.lineIndent(lastStart), multiline)
+	if node.kind == '{' {
+		first := node.fields[0]
+		s = marshalJSONString(key) + d.content[first.keyEnd:first.value.start] + s
+	}
+	return d.replace(lastEnd, lastEnd, ","+separator+s)
+}
+
+// render returns the value as JSON, which is indented relative to the given indentation of its line,
+// unless the file or the surrounding value isn't written on multiple lines.
+func (d *jsonDocument) render(value interface{}, lineIndent string, multiline bool) string {
+	if !multiline || d.indent == "" {
+		return encodeOrderedJSON(value, "")
+	}
+	return strings.ReplaceAll(encodeOrderedJSON(value, d.indent), "\n", d.newline+lineIndent)
+}
+
+// lineIndent returns the leading whitespace of the line of the offset.
+func (d *jsonDocument) lineIndent(offset int) string {
+	lineStart := strings.LastIndex(d.content[:offset], "\n") + 1
+	line := d.content[lineStart:offset]
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+func (d *jsonDocument) replace(start, end int, s string) error {
+	content := d.content[:start] + s + d.content[end:]
+	if _, err := decodeOrderedJSON(content); err != nil {
+		return fmt.Errorf("failed to patch JSON: %v", err)
+	}
+	d.content = content
+	return nil
+}
+
+// root returns the root value of the content, which has been validated to be JSON before.
+func (d *jsonDocument) root() *jsonNode {
+	offset := 0
+	return scanJSONNode(d.content, &offset)
+}
+
+// entries returns the start and end offsets of the fields or elements, where fields start at their key.
+func (n *jsonNode) entries() ([]int, []int) {
+	var starts, ends []int
+	for _, field := range n.fields {
+		starts, ends = append(starts, field.keyStart), append(ends, field.value.end)
+	}
+	for _, element := range n.elements {
+		starts, ends = append(starts, element.start), append(ends, element.end)
+	}
+	return starts, ends
+}
+
+// field returns the field of the object with the given key or nil.
+func (n *jsonNode) field(key string) *jsonField {
+	for i := range n.fields {
+		if n.fields[i].key == key {
+			return &n.fields[i]
+		}
+	}
+	return nil
+}
+
+// scanJSONNode returns the JSON value at the offset and moves the offset behind it.
+// The content must be valid JSON.
+func scanJSONNode(content string, offset *int) *jsonNode {
+	skipJSONSpaces(content, offset)
+	node := &jsonNode{start: *offset, kind: content[*offset]}
+	switch node.kind {
+	case '{', '[':
+		*offset++
+		skipJSONSpaces(content, offset)
+		for content[*offset] != '}' && content[*offset] != ']' {
+			if node.kind == '{' {
+				field := jsonField{keyStart: *offset}
+				field.key = scanJSONString(content, offset)
+				field.keyEnd = *offset
+				skipJSONSpaces(content, offset)
+				// the colon between the key and the value.
+				*offset++
+				field.value = scanJSONNode(content, offset)
+				node.fields = append(node.fields, field)
+			} else {
+				node.elements = append(node.elements, scanJSONNode(content, offset))
+			}
+			skipJSONSpaces(content, offset)
+			if content[*offset] == ',' {
+				*offset++
+				skipJSONSpaces(content, offset)
+			}
+		}
+		*offset++
+	case '"':
+		scanJSONString(content, offset)
+	default:
+		node.kind = 0
+		for *offset < len(content) && !strings.ContainsRune(" \t\r\n,]}", rune(content[*offset])) {
+			*offset++
+		}
+	}
+	node.end = *offset
+	return node
+}
+
+// scanJSONString returns the decoded JSON string at the offset and moves the offset behind it.
+func scanJSONString(content string, offset *int) string {
+	start := *offset
+	for *offset++; content[*offset] != '"'; *offset++ {
+		if content[*offset] == '\\' {
+			*offset++
+		}
+	}
+	*offset++
+
+	var s string
+	// the string has been validated before.
+	_ = json.Unmarshal([]byte(content[start:*offset]), &s)
+	return s
+}
+
+func skipJSONSpaces(content string, offset *int) {
+	for *offset < len(content) && strings.ContainsRune(" \t\r\n", rune(content[*offset])) {
+		*offset++
+	}
+}
+
+// detectNewline returns the line ending of the content, which is `\n` unless it uses `\r\n`.
+func detectNewline(content string) string {
+	if strings.Contains(content, "\r\n") {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// detectIndent returns the leading whitespace of the first indented line of the content
+// or the given default if no line is indented.
+func detectIndent(content, defaultIndent string) string {
+	for _, line := range strings.Split(content, "\n") {
+		trimmedLine := strings.TrimLeft(line, " \t")
+		if trimmedLine != "" && len(trimmedLine) < len(line) {
+			return line[:len(line)-len(trimmedLine)]
+		}
+	}
+	return defaultIndent
+}
@@ -0,0 +1,275 @@
+package provider
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDocumentPath(t *testing.T) {
+	cases := []struct {
+		givenDocumentPath string
+		expectedKeys      []string
+		expectedError     bool
+	}{
+		{givenDocumentPath: "/scripts/build", expectedKeys: []string{"scripts", "build"}},
+		{givenDocumentPath: "/a~1b/c~0d/0", expectedKeys: []string{"a/b", "c~d", "0"}},
+		{givenDocumentPath: "scripts.build", expectedKeys: []string{"scripts", "build"}},
+		{givenDocumentPath: "scripts..build", expectedError: true},
+		{givenDocumentPath: "", expectedError: true},
+	}
+
+	for _, c := range cases {
+		keys, err := parseDocumentPath(c.givenDocumentPath)
+		if (err != nil) != c.expectedError {
+			t.Fatalf("got error %v for %q; want error: %v", err, c.givenDocumentPath, c.expectedError)
+		}
+		if err == nil && !reflect.DeepEqual(keys, c.expectedKeys) {
+			t.Fatalf("got keys %q for %q; want %q", keys, c.givenDocumentPath, c.expectedKeys)
+		}
+	}
+}
+
+func TestValidateDocumentPath(t *testing.T) {
+	for _, documentPath := range []string{"/scripts/build", "/files/2", "files.2"} {
+		if _, errs := validateDocumentPath(documentPath, "document_path"); len(errs) != 0 {
+			t.Fatalf("got errors for valid document path %q: %v", documentPath, errs)
+		}
+	}
+	for _, documentPath := range []string{"", "scripts..build", "/files/-", "files.-.name"} {
+		if _, errs := validateDocumentPath(documentPath, "document_path"); len(errs) == 0 {
+			t.Fatalf("got no errors for invalid document path %q", documentPath)
+		}
+	}
+}
+
+func TestStructuredDocument(t *testing.T) {
+	cases := []struct {
+		format           string
+		givenContent     string
+		documentPath     string
+		value            string
+		expectedCurrent  string
+		expectedContent  string
+		expectedRemoved  string
+		expectedNotFound bool
+	}{
+		{
+			format:          structuredFormatJSON,
+			givenContent:    "{\n    \"name\": \"meow\",\n    \"scripts\": {\n        \"build\": \"make\",\n        \"test\": \"make test\"\n    }\n}\n",
+			documentPath:    "/scripts/build",
+			value:           `"tsc --build"`,
+			expectedCurrent: `"make"`,
+			expectedContent: "{\n    \"name\": \"meow\",\n    \"scripts\": {\n        \"build\": \"tsc --build\",\n        \"test\": \"make test\"\n    }\n}\n",
+			expectedRemoved: "{\n    \"name\": \"meow\",\n    \"scripts\": {\n        \"test\": \"make test\"\n    }\n}\n",
+		},
+		{
+			format:           structuredFormatJSON,
+			givenContent:     `{"name":"meow"}`,
+			documentPath:     "engines.node",
+			value:            `">=16"`,
+			expectedNotFound: true,
+			expectedContent:  `{"name":"meow","engines":{"node":">=16"}}`,
+			expectedRemoved:  `{"name":"meow","engines":{}}`,
+		},
+		{
+			format:           structuredFormatJSON,
+			givenContent:     "{\r\n  \"name\": \"m\\u00e9ow\",\r\n  \"files\": [\"a\", \"b\"]\r\n}\r\n",
+			documentPath:     "/scripts/build",
+			value:            `"make"`,
+			expectedNotFound: true,
+			expectedContent:  "{\r\n  \"name\": \"m\\u00e9ow\",\r\n  \"files\": [\"a\", \"b\"],\r\n  \"scripts\": {\r\n    \"build\": \"make\"\r\n  }\r\n}\r\n",
+			expectedRemoved:  "{\r\n  \"name\": \"m\\u00e9ow\",\r\n  \"files\": [\"a\", \"b\"],\r\n  \"scripts\": {}\r\n}\r\n",
+		},
+		{
+			format:           structuredFormatJSON,
+			givenContent:     "{\n  \"files\": [\"a\", \"b\"],\n  \"name\": \"meow\"\n}\n",
+			documentPath:     "/files/2",
+			value:            `{"c":true}`,
+			expectedNotFound: true,
+			expectedContent:  "{\n  \"files\": [\"a\", \"b\", {\"c\":true}],\n  \"name\": \"meow\"\n}\n",
+			expectedRemoved:  "{\n  \"files\": [\"a\", \"b\"],\n  \"name\": \"meow\"\n}\n",
+		},
+		{
+			format:          structuredFormatYAML,
+			givenContent:    "# the image\nimage: alpine:3.14 # pinned\nstages:\n    - build\n    - test\n",
+			documentPath:    "image",
+			value:           `"alpine:3.15"`,
+			expectedCurrent: `"alpine:3.14"`,
+			expectedContent: "# the image\nimage: alpine:3.15 # pinned\nstages:\n    - build\n    - test\n",
+			expectedRemoved: "stages:\n    - build\n    - test\n",
+		},
+		{
+			format:          structuredFormatYAML,
+			givenContent:    "stages:\n  - build\n  - test\n",
+			documentPath:    "/stages/1",
+			value:           `"deploy"`,
+			expectedCurrent: `"test"`,
+			expectedContent: "stages:\n  - build\n  - deploy\n",
+			expectedRemoved: "stages:\n  - build\n",
+		},
+		{
+			format:          structuredFormatYAML,
+			givenContent:    "variables:\n  A: \"1\"\n\nbuild:\n  script:\n    - make\n\ntest:\n  script:\n    - make test\n",
+			documentPath:    "variables.A",
+			value:           `"2"`,
+			expectedCurrent: `"1"`,
+			expectedContent: "variables:\n  A: \"2\"\n\nbuild:\n  script:\n    - make\n\ntest:\n  script:\n    - make test\n",
+			expectedRemoved: "variables: {}\n\nbuild:\n  script:\n    - make\n\ntest:\n  script:\n    - make test\n",
+		},
+		{
+			format:           structuredFormatYAML,
+			givenContent:     "build:\n  tags: [docker, linux] # runners\n\ntest: {}\n",
+			documentPath:     "build.tags.2",
+			value:            `"large"`,
+			expectedNotFound: true,
+			expectedContent:  "build:\n  tags: [docker, linux, large] # runners\n\ntest: {}\n",
+			expectedRemoved:  "build:\n  tags: [docker, linux] # runners\n\ntest: {}\n",
+		},
+		{
+			format:           structuredFormatYAML,
+			givenContent:     "",
+			documentPath:     "variables.DEBUG",
+			value:            `{"enabled":true,"level":2}`,
+			expectedNotFound: true,
+			expectedContent:  "variables:\n  DEBUG:\n    enabled: true\n    level: 2\n",
+			expectedRemoved:  "variables: {}\n",
+		},
+		{
+			format:           structuredFormatYAML,
+			givenContent:     "\ufeffvariables:\r\n  A: \"1\" # one\r\n",
+			documentPath:     "variables.B",
+			value:            `{"x":[1,2]}`,
+			expectedNotFound: true,
+			expectedContent:  "\ufeffvariables:\r\n  A: \"1\" # one\r\n  B:\r\n    x:\r\n      - 1\r\n      - 2\r\n",
+			expectedRemoved:  "\ufeffvariables:\r\n  A: \"1\" # one\r\n",
+		},
+		{
+			format:          structuredFormatTOML,
+			givenContent:    "# settings\nname = 'meow'\n\n[tool.black]\nline-length = 88 # default\ntarget = [\n  \"py38\",\n]\n\n[tool.isort]\nprofile = \"black\"\n",
+			documentPath:    "tool.black.line-length",
+			value:           `120`,
+			expectedCurrent: `88`,
+			expectedContent: "# settings\nname = 'meow'\n\n[tool.black]\nline-length = 120 # default\ntarget = [\n  \"py38\",\n]\n\n[tool.isort]\nprofile = \"black\"\n",
+			expectedRemoved: "# settings\nname = 'meow'\n\n[tool.black]\ntarget = [\n  \"py38\",\n]\n\n[tool.isort]\nprofile = \"black\"\n",
+		},
+		{
+			format:           structuredFormatTOML,
+			givenContent:     "name = 'meow'\n\n[tool.black]\nline-length = 88\n\n[tool.isort]\nprofile = \"black\"\n",
+			documentPath:     "/tool/black/skip magic",
+			value:            `["a", {"b": 1.5}]`,
+			expectedNotFound: true,
+			expectedContent:  "name = 'meow'\n\n[tool.black]\nline-length = 88\n\"skip magic\" = [\"a\", { b = 1.5 }]\n\n[tool.isort]\nprofile = \"black\"\n",
+			expectedRemoved:  "name = 'meow'\n\n[tool.black]\nline-length = 88\n\n[tool.isort]\nprofile = \"black\"\n",
+		},
+		{
+			format:          structuredFormatTOML,
+			givenContent:    "[dependencies]\nserde = { version = \"1.0\", features = [\"derive\"] }\n",
+			documentPath:    "dependencies.serde.version",
+			value:           `"1.1"`,
+			expectedCurrent: `"1.0"`,
+			expectedContent: "[dependencies]\nserde = { version = \"1.1\", features = [\"derive\"] }\n",
+			expectedRemoved: "[dependencies]\nserde = { features = [\"derive\"] }\n",
+		},
+		{
+			format:           structuredFormatTOML,
+			givenContent:     "[package]\r\nname = \"meow\"\r\n",
+			documentPath:     "package.edition",
+			value:            `"2021"`,
+			expectedNotFound: true,
+			expectedContent:  "[package]\r\nname = \"meow\"\r\nedition = \"2021\"\r\n",
+			expectedRemoved:  "[package]\r\nname = \"meow\"\r\n",
+		},
+		{
+			format:           structuredFormatTOML,
+			givenContent:     "[package]\nname = \"meow\"",
+			documentPath:     "package.edition",
+			value:            `"2021"`,
+			expectedNotFound: true,
+			expectedContent:  "[package]\nname = \"meow\"\nedition = \"2021\"\n",
+			expectedRemoved:  "[package]\nname = \"meow\"\n",
+		},
+	}
+
+	for _, c := range cases {
+		keys, err := parseDocumentPath(c.documentPath)
+		if err != nil {
+			t.Fatalf("failed to parse document path %q: %v", c.documentPath, err)
+		}
+		value, err := decodeOrderedJSON(c.value)
+		if err != nil {
+			t.Fatalf("failed to decode value %q: %v", c.value, err)
+		}
+
+		document, err := parseStructuredDocument(c.format, c.givenContent)
+		if err != nil {
+			t.Fatalf("failed to parse %s %q: %v", c.format, c.givenContent, err)
+		}
+		current, ok, err := document.Get(keys)
+		if err != nil {
+			t.Fatalf("failed to get %q of %q: %v", c.documentPath, c.givenContent, err)
+		}
+		if ok == c.expectedNotFound || (ok && encodeOrderedJSON(current, "") != c.expectedCurrent) {
+			t.Fatalf("got %q (found: %v) at %q of %q; want %q", encodeOrderedJSON(current, ""), ok, c.documentPath, c.givenContent, c.expectedCurrent)
+		}
+
+		if err := document.Set(keys, value); err != nil {
+			t.Fatalf("failed to set %q of %q: %v", c.documentPath, c.givenContent, err)
+		}
+		content, err := document.String()
+		if err != nil || content != c.expectedContent {
+			t.Fatalf("got %q (error: %v) after setting %q of %q; want %q", content, err, c.documentPath, c.givenContent, c.expectedContent)
+		}
+
+		// the patched content is parsed again, like on the next apply.
+		document, err = parseStructuredDocument(c.format, content)
+		if err != nil {
+			t.Fatalf("failed to parse %s %q: %v", c.format, content, err)
+		}
+		current, ok, err = document.Get(keys)
+		if err != nil || !ok || !structuredValuesEqual(current, value) {
+			t.Fatalf("got %q (found: %v, error: %v) at %q of %q; want %q", encodeOrderedJSON(current, ""), ok, err, c.documentPath, content, c.value)
+		}
+
+		if err := document.Remove(keys); err != nil {
+			t.Fatalf("failed to remove %q of %q: %v", c.documentPath, content, err)
+		}
+		removed, err := document.String()
+		if err != nil || removed != c.expectedRemoved {
+			t.Fatalf("got %q (error: %v) after removing %q of %q; want %q", removed, err, c.documentPath, content, c.expectedRemoved)
+		}
+	}
+}
+
+func TestStructuredDocument_tomlErrors(t *testing.T) {
+	document, err := parseTOMLDocument("[[bin]]\nname = \"meow\"\n\n[tool]\nx = 1\n")
+	if err != nil {
+		t.Fatalf("failed to parse TOML: %v", err)
+	}
+
+	if _, _, err := document.Get([]string{"bin", "0", "name"}); err == nil {
+		t.Fatalf("expected error for a key in an array of tables")
+	}
+	if err := document.Set([]string{"tool"}, orderedObject{{Key: "y", Value: true}}); err == nil {
+		t.Fatalf("expected error for replacing a table")
+	}
+	if err := document.Set([]string{"tool", "x"}, nil); err == nil {
+		t.Fatalf("expected error for setting null")
+	}
+
+	if _, err := parseTOMLDocument("name = \"meow\n"); err == nil {
+		t.Fatalf("expected error for an unterminated string")
+	}
+}
+
+func TestStructuredValuesEqual(t *testing.T) {
+	a, _ := decodeOrderedJSON(`{"a": 1.0, "b": [true, null]}`)
+	b, _ := decodeOrderedJSON(`{"b": [true, null], "a": 1}`)
+	c, _ := decodeOrderedJSON(`{"a": 1, "b": [false, null]}`)
+
+	if !structuredValuesEqual(a, b) {
+		t.Fatalf("expected %q to equal %q", encodeOrderedJSON(a, ""), encodeOrderedJSON(b, ""))
+	}
+	if structuredValuesEqual(a, c) {
+		t.Fatalf("expected %q to differ from %q", encodeOrderedJSON(a, ""), encodeOrderedJSON(c, ""))
+	}
+}
@@ -0,0 +1,639 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var tomlBareKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// tomlDocument is a TOML file, which is patched in its text, so that comments and formatting are kept.
+// Only the value of a changed key is rewritten, a new key is added after the last key of its table.
+// Arrays of tables can't be addressed.
+type tomlDocument struct {
+	content string
+	newline string
+	tables  []tomlTable
+	entries []tomlEntry
+}
+
+// tomlTable is a table header of a TOML file. The first table is the root table without a header.
+type tomlTable struct {
+	keys      []string
+	array     bool
+	start     int
+	headerEnd int
+	end       int
+}
+
+// tomlEntry is a key/value line of a TOML file with the full keys of the value including its table.
+type tomlEntry struct {
+	table      int
+	keys       []string
+	value      interface{}
+	lineStart  int
+	valueStart int
+	valueEnd   int
+	lineEnd    int
+}
+
+func parseTOMLDocument(content string) (*tomlDocument, error) {
+	d := &tomlDocument{content: content, newline: detectNewline(content), tables: []tomlTable{{}}}
+	p := &tomlParser{content: content}
+
+	for p.pos < len(content) {
+		lineStart := p.pos
+		p.skipSpaces()
+
+		switch {
+		case p.peek("#"), p.peek("\n"), p.peek("\r\n"), p.pos == len(content):
+			if err := p.skipLineEnd(); err != nil {
+				return nil, err
+			}
+		case p.peek("["):
+			array := p.peek("[[")
+			closing := "]"
+			if array {
+				closing = "]]"
+			}
+			p.pos += len(closing)
+
+			keys, err := p.parseKey()
+			if err != nil {
+				return nil, err
+			}
+			if !p.peek(closing) {
+				return nil, p.errorf("expected %s after table header", closing)
+			}
+			p.pos += len(closing)
+			if err := p.skipLineEnd(); err != nil {
+				return nil, err
+			}
+
+			d.tables[len(d.tables)-1].end = lineStart
+			d.tables = append(d.tables, tomlTable{keys: keys, array: array, start: lineStart, headerEnd: p.pos})
+		default:
+			keys, err := p.parseKey()
+			if err != nil {
+				return nil, err
+			}
+			if !p.peek("=") {
+				return nil, p.errorf("expected = after key")
+			}
+			p.pos++
+			p.skipSpaces()
+
+			valueStart := p.pos
+			value, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			valueEnd := p.pos
+			if err := p.skipLineEnd(); err != nil {
+				return nil, err
+			}
+
+			table := len(d.tables) - 1
+			d.entries = append(d.entries, tomlEntry{
+				table:      table,
+				keys:       append(append([]string{}, d.tables[table].keys...), keys...),
+				value:      value,
+				lineStart:  lineStart,
+				valueStart: valueStart,
+				valueEnd:   valueEnd,
+				lineEnd:    p.pos,
+			})
+		}
+	}
+	d.tables[len(d.tables)-1].end = len(content)
+
+	return d, nil
+}
+
+func (d *tomlDocument) Get(keys []string) (interface{}, bool, error) {
+	if err := d.checkAddressable(keys); err != nil {
+		return nil, false, err
+	}
+
+	if entry := d.findEntry(keys); entry != nil {
+		value, ok := getOrderedValue(entry.value, keys[len(entry.keys):])
+		return value, ok, nil
+	}
+
+	// a table is assembled from all keys below it.
+	var object interface{}
+	found := false
+	for _, table := range d.tables[1:] {
+		if hasKeyPrefix(table.keys, keys) {
+			found = true
+		}
+	}
+	for _, entry := range d.entries {
+		if len(entry.keys) > len(keys) && hasKeyPrefix(entry.keys, keys) {
+			var err error
+			if object, err = setOrderedValue(object, entry.keys[len(keys):], entry.value); err != nil {
+				return nil, false, err
+			}
+			found = true
+		}
+	}
+	if !found {
+		return nil, false, nil
+	}
+	if object == nil {
+		object = orderedObject{}
+	}
+	return object, true, nil
+}
+
+func (d *tomlDocument) Set(keys []string, value interface{}) error {
+	if err := d.checkAddressable(keys); err != nil {
+		return err
+	}
+
+	if entry := d.findEntry(keys); entry != nil {
+		entryValue, err := setOrderedValue(entry.value, keys[len(entry.keys):], value)
+		if err != nil {
+			return err
+		}
+		renderedValue, err := renderTOMLValue(entryValue)
+		if err != nil {
+			return err
+		}
+		return d.replace(entry.valueStart, entry.valueEnd, renderedValue)
+	}
+
+	if d.isTable(keys) {
+		return fmt.Errorf("key %s is a table, which can't be replaced as a whole, set its keys individually", renderTOMLKey(keys))
+	}
+
+	// the key is added to the most specific table which contains it.
+	table := 0
+	for i, t := range d.tables {
+		if len(t.keys) < len(keys) && hasKeyPrefix(keys, t.keys) && len(t.keys) >= len(d.tables[table].keys) {
+			table = i
+		}
+	}
+	offset := d.tables[table].headerEnd
+	for _, entry := range d.entries {
+		if entry.table == table {
+			offset = entry.lineEnd
+		}
+	}
+
+	renderedValue, err := renderTOMLValue(value)
+	if err != nil {
+		return err
+	}
+	line := renderTOMLKey(keys[len(d.tables[table].keys):]) + " = " + renderedValue + d.newline
+	if offset > 0 && d.content[offset-1] != '\n' {
+		line = d.newline + line
+	}
+	return d.replace(offset, offset, line)
+}
+
+func (d *tomlDocument) Remove(keys []string) error {
+	if err := d.checkAddressable(keys); err != nil {
+		return err
+	}
+
+	if entry := d.findEntry(keys); entry != nil {
+		if len(entry.keys) == len(keys) {
+			return d.replace(entry.lineStart, entry.lineEnd, "")
+		}
+		renderedValue, err := renderTOMLValue(removeOrderedValue(entry.value, keys[len(entry.keys):]))
+		if err != nil {
+			return err
+		}
+		return d.replace(entry.valueStart, entry.valueEnd, renderedValue)
+	}
+
+	// a table is removed with its headers and all keys below it.
+	type span struct{ start, end int }
+	var spans []span
+	for _, table := range d.tables[1:] {
+		if hasKeyPrefix(table.keys, keys) {
+			spans = append(spans, span{table.start, table.end})
+		}
+	}
+	for _, entry := range d.entries {
+		if hasKeyPrefix(entry.keys, keys) && !hasKeyPrefix(d.tables[entry.table].keys, keys) {
+			spans = append(spans, span{entry.lineStart, entry.lineEnd})
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start > spans[j].start })
+
+	content := d.content
+	for _, s := range spans {
+		content = content[:s.start] + content[s.end:]
+	}
+	return d.replace(0, len(d.content), content)
+}
+
+func (d *tomlDocument) String() (string, error) {
+	return d.content, nil
+}
+
+// findEntry returns the entry whose keys are the given keys or a prefix of them.
+func (d *tomlDocument) findEntry(keys []string) *tomlEntry {
+	for i, entry := range d.entries {
+		if hasKeyPrefix(keys, entry.keys) {
+			return &d.entries[i]
+		}
+	}
+	return nil
+}
+
+// isTable returns true if the keys address a table, either by a header or by dotted keys below it.
+func (d *tomlDocument) isTable(keys []string) bool {
+	for _, table := range d.tables[1:] {
+		if hasKeyPrefix(table.keys, keys) {
+			return true
+		}
+	}
+	for _, entry := range d.entries {
+		if len(entry.keys) > len(keys) && hasKeyPrefix(entry.keys, keys) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *tomlDocument) checkAddressable(keys []string) error {
+	for _, table := range d.tables {
+		if table.array && (hasKeyPrefix(keys, table.keys) || hasKeyPrefix(table.keys, keys)) {
+			return fmt.Errorf("arrays of tables like [[%s]] are not supported", renderTOMLKey(table.keys))
+		}
+	}
+	return nil
+}
+
+// replace replaces the content between the offsets and parses the document again.
+func (d *tomlDocument) replace(start, end int, s string) error {
+	document, err := parseTOMLDocument(d.content[:start] + s + d.content[end:])
+	if err != nil {
+		return fmt.Errorf("failed to patch TOML: %v", err)
+	}
+	*d = *document
+	return nil
+}
+
+// hasKeyPrefix returns true if the keys start with the prefix.
+func hasKeyPrefix(keys, prefix []string) bool {
+	if len(prefix) > len(keys) {
+		return false
+	}
+	for i, key := range prefix {
+		if keys[i] != key {
+			return false
+		}
+	}
+	return true
+}
+
+// renderTOMLKey returns the dotted TOML key of the keys, which are quoted if necessary.
+func renderTOMLKey(keys []string) string {
+	renderedKeys := make([]string, len(keys))
+	for i, key := range keys {
+		renderedKeys[i] = key
+		if !tomlBareKeyPattern.MatchString(key) {
+			renderedKeys[i] = marshalJSONString(key)
+		}
+	}
+	return strings.Join(renderedKeys, ".")
+}
+
+// renderTOMLValue returns the given decoded JSON value as inline TOML value.
+func renderTOMLValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case orderedObject:
+		if len(v) == 0 {
+			return "{}", nil
+		}
+		fields := make([]string, len(v))
+		for i, field := range v {
+			renderedValue, err := renderTOMLValue(field.Value)
+			if err != nil {
+				return "", err
+			}
+			fields[i] = renderTOMLKey([]string{field.Key}) + " = " + renderedValue
+		}
+		return "{ " + strings.Join(fields, ", ") + " }", nil
+	case []interface{}:
+		elements := make([]string, len(v))
+		for i, element := range v {
+			renderedElement, err := renderTOMLValue(element)
+			if err != nil {
+				return "", err
+			}
+			elements[i] = renderedElement
+		}
+		return "[" + strings.Join(elements, ", ") + "]", nil
+	case string:
+		// JSON strings are valid TOML basic strings.
+		return marshalJSONString(v), nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case nil:
+		return "", fmt.Errorf("null can't be represented in TOML")
+	}
+	return "", fmt.Errorf("unexpected value of type %T", value)
+}
+
+// tomlParser is a minimal parser of TOML, which only decodes the values of keys,
+// so that their positions in the content are known.
+type tomlParser struct {
+	content string
+	pos     int
+}
+
+func (p *tomlParser) errorf(format string, args ...interface{}) error {
+	line := strings.Count(p.content[:p.pos], "\n") + 1
+	return fmt.Errorf("failed to parse TOML at line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+func (p *tomlParser) peek(s string) bool {
+	return strings.HasPrefix(p.content[p.pos:], s)
+}
+
+func (p *tomlParser) skipSpaces() {
+	for p.pos < len(p.content) && (p.content[p.pos] == ' ' || p.content[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *tomlParser) skipComment() {
+	if p.peek("#") {
+		for p.pos < len(p.content) && p.content[p.pos] != '\n' {
+			p.pos++
+		}
+	}
+}
+
+// skipLineEnd skips the rest of a line, which may only contain a comment.
+func (p *tomlParser) skipLineEnd() error {
+	p.skipSpaces()
+	p.skipComment()
+	switch {
+	case p.peek("\r\n"):
+		p.pos += 2
+	case p.peek("\n"):
+		p.pos++
+	case p.pos < len(p.content):
+		return p.errorf("unexpected %q", p.content[p.pos])
+	}
+	return nil
+}
+
+// skipBlank skips whitespace, newlines and comments, e.g. between the elements of an array.
+func (p *tomlParser) skipBlank() {
+	for {
+		p.skipSpaces()
+		p.skipComment()
+		switch {
+		case p.peek("\r\n"):
+			p.pos += 2
+		case p.peek("\n"):
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+// parseKey parses a dotted key and skips the spaces after it.
+func (p *tomlParser) parseKey() ([]string, error) {
+	var keys []string
+	for {
+		p.skipSpaces()
+		var key string
+		if p.peek(`"`) || p.peek("'") {
+			var err error
+			if key, err = p.parseString(); err != nil {
+				return nil, err
+			}
+		} else {
+			start := p.pos
+			for p.pos < len(p.content) && tomlBareKeyPattern.MatchString(p.content[p.pos:p.pos+1]) {
+				p.pos++
+			}
+			if start == p.pos {
+				return nil, p.errorf("expected a key")
+			}
+			key = p.content[start:p.pos]
+		}
+		keys = append(keys, key)
+
+		p.skipSpaces()
+		if !p.peek(".") {
+			return keys, nil
+		}
+		p.pos++
+	}
+}
+
+func (p *tomlParser) parseValue() (interface{}, error) {
+	switch {
+	case p.peek(`"`), p.peek("'"):
+		return p.parseString()
+	case p.peek("["):
+		p.pos++
+		array := []interface{}{}
+		for {
+			p.skipBlank()
+			if p.peek("]") {
+				p.pos++
+				return array, nil
+			}
+			element, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, element)
+
+			p.skipBlank()
+			if p.peek(",") {
+				p.pos++
+			} else if !p.peek("]") {
+				return nil, p.errorf("expected , or ] in array")
+			}
+		}
+	case p.peek("{"):
+		p.pos++
+		var object interface{} = orderedObject{}
+		for {
+			p.skipSpaces()
+			if p.peek("}") {
+				p.pos++
+				return object, nil
+			}
+			keys, err := p.parseKey()
+			if err != nil {
+				return nil, err
+			}
+			if !p.peek("=") {
+				return nil, p.errorf("expected = after key")
+			}
+			p.pos++
+			p.skipSpaces()
+			value, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			if object, err = setOrderedValue(object, keys, value); err != nil {
+				return nil, p.errorf("%v", err)
+			}
+
+			p.skipSpaces()
+			if p.peek(",") {
+				p.pos++
+			} else if !p.peek("}") {
+				return nil, p.errorf("expected , or } in inline table")
+			}
+		}
+	}
+
+	start := p.pos
+	for p.pos < len(p.content) && !strings.ContainsRune(",]}#\r\n", rune(p.content[p.pos])) {
+		p.pos++
+	}
+	raw := strings.TrimRight(p.content[start:p.pos], " \t")
+	p.pos = start + len(raw)
+	if raw == "" {
+		return nil, p.errorf("expected a value")
+	}
+	return tomlScalarValue(raw), nil
+}
+
+// tomlScalarValue returns the decoded JSON value of a boolean, a number, a date or a time.
+// Dates, times, infinity and NaN are returned as their text.
+func tomlScalarValue(raw string) interface{} {
+	switch raw {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+
+	number := strings.ReplaceAll(raw, "_", "")
+	if i, err := strconv.ParseInt(number, 0, 64); err == nil {
+		return json.Number(strconv.FormatInt(i, 10))
+	}
+	if f, err := strconv.ParseFloat(number, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+		return json.Number(strconv.FormatFloat(f, 'g', -1, 64))
+	}
+	return raw
+}
+
+// parseString parses a basic, literal or multi-line string.
+func (p *tomlParser) parseString() (string, error) {
+	for _, delimiter := range []string{`"""`, `'''`, `"`, `'`} {
+		if !p.peek(delimiter) {
+			continue
+		}
+		p.pos += len(delimiter)
+
+		escapes := delimiter[0] == '"'
+		multiline := len(delimiter) == 3
+		end := -1
+		for i := p.pos; i < len(p.content); i++ {
+			if escapes && p.content[i] == '\\' {
+				i++
+				continue
+			}
+			if strings.HasPrefix(p.content[i:], delimiter) {
+				end = i
+				break
+			}
+		}
+		if end < 0 {
+			return "", p.errorf("unterminated string")
+		}
+		// up to two quotes may directly precede the closing delimiter of a multi-line string.
+		for i := 0; multiline && i < 2 && strings.HasPrefix(p.content[end+1:], delimiter); i++ {
+			end++
+		}
+
+		s := p.content[p.pos:end]
+		p.pos = end + len(delimiter)
+		if !multiline && strings.ContainsAny(s, "\r\n") {
+			return "", p.errorf("unexpected newline in string")
+		}
+		if multiline {
+			// a newline directly after the opening delimiter is trimmed.
+			if strings.HasPrefix(s, "\r\n") {
+				s = s[2:]
+			}
+			s = strings.TrimPrefix(s, "\n")
+		}
+		if !escapes {
+			return s, nil
+		}
+
+		unescaped, err := unescapeTOMLString(s, multiline)
+		if err != nil {
+			return "", p.errorf("%v", err)
+		}
+		return unescaped, nil
+	}
+	return "", p.errorf("expected a string")
+}
+
+// unescapeTOMLString replaces the escape sequences of a basic string.
+func unescapeTOMLString(s string, multiline bool) (string, error) {
+	var buf strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			buf.WriteByte(s[i])
+			continue
+		}
+		i++
+		if i == len(s) {
+			return "", fmt.Errorf("unterminated escape sequence")
+		}
+
+		switch s[i] {
+		case 'b':
+			buf.WriteByte('\b')
+		case 't':
+			buf.WriteByte('\t')
+		case 'n':
+			buf.WriteByte('\n')
+		case 'f':
+			buf.WriteByte('\f')
+		case 'r':
+			buf.WriteByte('\r')
+		case '"', '\\':
+			buf.WriteByte(s[i])
+		case 'u', 'U':
+			size := 4
+			if s[i] == 'U' {
+				size = 8
+			}
+			if i+size >= len(s) {
+				return "", fmt.Errorf("invalid unicode escape sequence")
+			}
+			code, err := strconv.ParseUint(s[i+1:i+1+size], 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("invalid unicode escape sequence: %v", err)
+			}
+			buf.WriteRune(rune(code))
+			i += size
+		default:
+			// a backslash at the end of a line of a multi-line string trims all following whitespace.
+			rest := strings.TrimLeft(s[i:], " \t")
+			if !multiline || !(strings.HasPrefix(rest, "\n") || strings.HasPrefix(rest, "\r\n")) {
+				return "", fmt.Errorf("invalid escape sequence \\%c", s[i])
+			}
+			i = len(s) - len(strings.TrimLeft(rest, " \t\r\n")) - 1
+		}
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,693 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlDocument is a YAML file, which is patched in its text, so that comments and formatting are kept.
+// Only the text of a changed value is rewritten, new keys and elements are rendered by the YAML encoder
+// and added after the last entry of their mapping or sequence.
+type yamlDocument struct {
+	content    string
+	root       *yaml.Node
+	lineStarts []int
+	indent     int
+	newline    string
+}
+
+// yamlSlot is the position of a value in the text of a YAML document.
+type yamlSlot struct {
+	node *yaml.Node
+	// flow is true if the value is an entry of a flow collection like `[a, b]`.
+	flow bool
+	// indent is the column of the key or the `-` of the value's entry, -1 for the root.
+	indent int
+	// indicator is the offset after the `:` or `-` of the value's entry.
+	indicator int
+}
+
+func parseYAMLDocument(content string) (*yamlDocument, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &document); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %v", err)
+	}
+
+	d := &yamlDocument{content: content, lineStarts: []int{0}, newline: detectNewline(content)}
+	for i := 0; i < len(content); i++ {
+		if content[i] == '\n' {
+			d.lineStarts = append(d.lineStarts, i+1)
+		}
+	}
+	// a document without any node, e.g. an empty one, has no root yet.
+	if document.Kind != 0 {
+		d.root = document.Content[0]
+	}
+
+	// the YAML encoder only supports indents between 2 and 9 spaces.
+	d.indent = len(detectIndent(content, "  "))
+	if d.indent < 2 || d.indent > 9 {
+		d.indent = 2
+	}
+
+	return d, nil
+}
+
+func (d *yamlDocument) Get(keys []string) (interface{}, bool, error) {
+	node := d.find(keys)
+	if node == nil {
+		return nil, false, nil
+	}
+	value, err := yamlNodeValue(node)
+	return value, err == nil, err
+}
+
+func (d *yamlDocument) Set(keys []string, value interface{}) error {
+	// the keys are copied, because an appended element's `-` key is replaced by its index.
+	keys = append([]string{}, keys...)
+	if err := d.set(keys, value); err != nil {
+		return err
+	}
+
+	// the text is checked, in case the patch changed the meaning of the surrounding YAML.
+	current, ok, err := d.Get(keys)
+	if err != nil || !ok || !structuredValuesEqual(current, value) {
+		return fmt.Errorf("failed to patch YAML: key %s has not been set correctly", strings.Join(keys, "."))
+	}
+	return nil
+}
+
+func (d *yamlDocument) set(keys []string, value interface{}) error {
+	if d.root == nil {
+		// the document is empty, except for comments.
+		content := d.content
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			content += d.newline
+		}
+		return d.replace(0, len(d.content), content+d.renderIndented(yamlValueNode(nestedOrderedValue(keys, value)), 0)+d.newline)
+	}
+
+	slot := yamlSlot{node: d.root, indent: -1, indicator: d.nodeStart(d.root)}
+	for i, key := range keys {
+		node := slot.node
+		switch node.Kind {
+		case yaml.MappingNode:
+			index := yamlMappingIndex(node, key)
+			if index < 0 {
+				return d.addEntry(slot, orderedObject{{Key: key, Value: nestedOrderedValue(keys[i+1:], value)}})
+			}
+			slot = d.mappingSlot(slot, index)
+		case yaml.SequenceNode:
+			index, err := strconv.Atoi(key)
+			if key == "-" {
+				index, err = len(node.Content), nil
+				keys[i] = strconv.Itoa(index)
+			}
+			if err != nil || index < 0 || index > len(node.Content) {
+				return fmt.Errorf("invalid index %q of a sequence with %d elements", key, len(node.Content))
+			}
+			if index == len(node.Content) {
+				return d.addEntry(slot, []interface{}{nestedOrderedValue(keys[i+1:], value)})
+			}
+			slot = d.sequenceSlot(slot, index)
+		default:
+			return fmt.Errorf("key %q can't be set, because its parent is neither a mapping nor a sequence", key)
+		}
+	}
+	return d.replaceValue(slot, value)
+}
+
+func (d *yamlDocument) Remove(keys []string) error {
+	if d.root == nil {
+		return nil
+	}
+
+	slot := yamlSlot{node: d.root, indent: -1, indicator: d.nodeStart(d.root)}
+	var parent yamlSlot
+	index := -1
+	for _, key := range keys {
+		parent, index = slot, -1
+		switch node := slot.node; node.Kind {
+		case yaml.MappingNode:
+			if index = yamlMappingIndex(node, key); index >= 0 {
+				slot = d.mappingSlot(slot, index)
+			}
+		case yaml.SequenceNode:
+			if i, err := strconv.Atoi(key); err == nil && i >= 0 && i < len(node.Content) {
+				index = i
+				slot = d.sequenceSlot(slot, index)
+			}
+		}
+		if index < 0 {
+			return nil
+		}
+	}
+
+	entries := len(parent.node.Content)
+	if parent.node.Kind == yaml.MappingNode {
+		entries /= 2
+	}
+	if entries == 1 {
+		// the last entry is removed by replacing its collection with an empty one.
+		if parent.node.Kind == yaml.MappingNode {
+			return d.replaceValue(parent, orderedObject{})
+		}
+		return d.replaceValue(parent, []interface{}{})
+	}
+
+	entryStart, entryEnd := d.entryStart(parent, index), d.nodeEnd(slot)
+	if parent.flow || parent.node.Style&yaml.FlowStyle != 0 {
+		// the separator to the next entry or, for the last entry, to the previous one is removed as well.
+		if index+1 < entries {
+			entryEnd = d.entryStart(parent, index+1)
+		} else {
+			entryStart = d.nodeEnd(d.entrySlot(parent, index-1))
+		}
+		return d.replace(entryStart, entryEnd, "")
+	}
+
+	lineStart := d.lineStart(entryStart)
+	if strings.TrimSpace(d.content[lineStart:entryStart]) != "" {
+		// the entry follows the `-` of its parent, like `- a: 1`, so the next entry takes its place.
+		return d.replace(entryStart, d.entryStart(parent, index+1), "")
+	}
+	// the comment lines directly above the entry belong to it.
+	for lineStart > 0 {
+		previousLineStart := d.lineStart(lineStart - 1)
+		if !strings.HasPrefix(strings.TrimSpace(d.content[previousLineStart:lineStart]), "#") {
+			break
+		}
+		lineStart = previousLineStart
+	}
+	lineEnd := d.lineEnd(entryEnd)
+	if lineEnd < len(d.content) {
+		lineEnd++
+	}
+	return d.replace(lineStart, lineEnd, "")
+}
+
+func (d *yamlDocument) String() (string, error) {
+	return d.content, nil
+}
+
+// find returns the node at the keys or nil if it doesn't exist.
+func (d *yamlDocument) find(keys []string) *yaml.Node {
+	node := d.root
+	if node == nil {
+		return nil
+	}
+	for _, key := range keys {
+		for node.Kind == yaml.AliasNode {
+			node = node.Alias
+		}
+
+		var child *yaml.Node
+		switch node.Kind {
+		case yaml.MappingNode:
+			if index := yamlMappingIndex(node, key); index >= 0 {
+				child = node.Content[2*index+1]
+			}
+		case yaml.SequenceNode:
+			if index, err := strconv.Atoi(key); err == nil && index >= 0 && index < len(node.Content) {
+				child = node.Content[index]
+			}
+		}
+		if child == nil {
+			return nil
+		}
+		node = child
+	}
+	return node
+}
+
+// replaceValue replaces the text of the value in the slot. A scalar which is replaced by a scalar
+// keeps its style, anchor and tag, any other value is rendered by the YAML encoder.
+func (d *yamlDocument) replaceValue(slot yamlSlot, value interface{}) error {
+	old := slot.node
+	start, end := d.nodeStart(old), d.nodeEnd(slot)
+	node := yamlValueNode(value)
+
+	if old.Kind == yaml.ScalarNode && node.Kind == yaml.ScalarNode {
+		if node.Tag == old.ShortTag() {
+			node.Style = old.Style &^ (yaml.TaggedStyle | yaml.FlowStyle)
+		}
+		if slot.flow {
+			return d.replace(d.skipProperties(old, start), end, d.renderFlow(node, value))
+		}
+		if start == end {
+			// an empty value like `key:` gets a space after its indicator.
+			return d.replace(start, end, " "+d.renderIndented(node, slot.indent))
+		}
+		return d.replace(d.skipProperties(old, start), end, d.renderIndented(node, slot.indent))
+	}
+
+	if slot.flow {
+		return d.replace(start, end, d.renderFlow(node, value))
+	}
+	if slot.indent < 0 {
+		return d.replace(start, end, d.renderIndented(node, 0))
+	}
+
+	// a block collection below a key starts on the next line, a sequence element on the line of its `-`.
+	block := len(node.Content) > 0
+	rendered := d.renderIndented(node, slot.indent+d.indent)
+	if old.Line > 0 && d.content[slot.indicator-1] == '-' {
+		block, rendered = false, d.renderIndented(node, slot.indent+2)
+	}
+
+	indicatorLineEnd := d.lineBreak(slot.indicator)
+	if start > indicatorLineEnd {
+		// the comment after the indicator is kept.
+		keep := d.content[slot.indicator:indicatorLineEnd]
+		if block {
+			return d.replace(slot.indicator, end, keep+d.newline+strings.Repeat(" ", slot.indent+d.indent)+rendered)
+		}
+		return d.replace(slot.indicator, end, " "+rendered+keep)
+	}
+	if block {
+		// the comment after the value is kept.
+		endLineEnd := d.lineBreak(end)
+		return d.replace(slot.indicator, endLineEnd, d.content[end:endLineEnd]+d.newline+strings.Repeat(" ", slot.indent+d.indent)+rendered)
+	}
+	return d.replace(slot.indicator, end, " "+rendered)
+}
+
+// addEntry adds the entries of the mapping or sequence value to the collection in the slot.
+func (d *yamlDocument) addEntry(slot yamlSlot, value interface{}) error {
+	node := slot.node
+	entries := len(node.Content)
+	if node.Kind == yaml.MappingNode {
+		entries /= 2
+	}
+	if entries == 0 {
+		// an empty collection is always a flow collection like `{}`, which is replaced as a whole.
+		return d.replaceValue(slot, value)
+	}
+
+	if slot.flow || node.Style&yaml.FlowStyle != 0 {
+		var rendered string
+		switch v := value.(type) {
+		case orderedObject:
+			rendered = d.renderFlow(yamlValueNode(v[0].Key), v[0].Key) + ": " + d.renderFlow(yamlValueNode(v[0].Value), v[0].Value)
+		case []interface{}:
+			rendered = d.renderFlow(yamlValueNode(v[0]), v[0])
+		}
+		lastEnd := d.nodeEnd(d.entrySlot(slot, entries-1))
+		return d.replace(lastEnd, lastEnd, ", "+rendered)
+	}
+
+	indent := d.entryIndent(slot, 0)
+	lastLineEnd := d.lineBreak(d.nodeEnd(d.entrySlot(slot, entries-1)))
+	return d.replace(lastLineEnd, lastLineEnd, d.newline+strings.Repeat(" ", indent)+d.renderIndented(yamlValueNode(value), indent))
+}
+
+// mappingSlot returns the slot of the value of the entry at the index of the mapping in the slot.
+func (d *yamlDocument) mappingSlot(slot yamlSlot, index int) yamlSlot {
+	key := slot.node.Content[2*index]
+	flow := slot.flow || slot.node.Style&yaml.FlowStyle != 0
+	keyStart := d.skipProperties(key, d.nodeStart(key))
+
+	indicator := d.scalarEnd(key, keyStart, flow, true, -1)
+	for indicator < len(d.content) && d.content[indicator] != ':' {
+		indicator++
+	}
+	return yamlSlot{
+		node:      slot.node.Content[2*index+1],
+		flow:      flow,
+		indent:    d.column(d.nodeStart(key)),
+		indicator: indicator + 1,
+	}
+}
+
+// sequenceSlot returns the slot of the element at the index of the sequence in the slot.
+func (d *yamlDocument) sequenceSlot(slot yamlSlot, index int) yamlSlot {
+	element := slot.node.Content[index]
+	if slot.flow || slot.node.Style&yaml.FlowStyle != 0 {
+		return yamlSlot{node: element, flow: true, indent: slot.indent, indicator: d.nodeStart(element)}
+	}
+
+	dash := d.nodeStart(element) - 1
+	for dash > 0 && d.content[dash] != '-' {
+		dash--
+	}
+	return yamlSlot{node: element, indent: d.column(dash), indicator: dash + 1}
+}
+
+// entrySlot returns the slot of the value of the entry at the index of the collection in the slot.
+func (d *yamlDocument) entrySlot(slot yamlSlot, index int) yamlSlot {
+	if slot.node.Kind == yaml.MappingNode {
+		return d.mappingSlot(slot, index)
+	}
+	return d.sequenceSlot(slot, index)
+}
+
+// entryStart returns the offset of the key or the `-` of the entry at the index of the collection in the slot.
+func (d *yamlDocument) entryStart(slot yamlSlot, index int) int {
+	if slot.node.Kind == yaml.MappingNode {
+		return d.nodeStart(slot.node.Content[2*index])
+	}
+	entry := d.sequenceSlot(slot, index)
+	if entry.flow {
+		return entry.indicator
+	}
+	return entry.indicator - 1
+}
+
+// entryIndent returns the column of the entry at the index of the block collection in the slot.
+func (d *yamlDocument) entryIndent(slot yamlSlot, index int) int {
+	return d.column(d.entryStart(slot, index))
+}
+
+// nodeStart returns the offset of the node, including its anchor and tag.
+func (d *yamlDocument) nodeStart(node *yaml.Node) int {
+	if node.Line == 0 || node.Line > len(d.lineStarts) {
+		return 0
+	}
+	offset := d.lineStarts[node.Line-1]
+	// the column counts characters, not bytes.
+	for i := 1; i < node.Column && offset < len(d.content); i++ {
+		_, size := utf8.DecodeRuneInString(d.content[offset:])
+		offset += size
+	}
+	return offset
+}
+
+// nodeEnd returns the offset after the text of the value in the slot, without a trailing comment.
+func (d *yamlDocument) nodeEnd(slot yamlSlot) int {
+	node := slot.node
+	start := d.skipProperties(node, d.nodeStart(node))
+
+	switch node.Kind {
+	case yaml.AliasNode:
+		return start + 1 + len(node.Value)
+	case yaml.ScalarNode:
+		if node.Tag == "!!null" && node.Value == "" && node.Style == 0 {
+			return start
+		}
+		return d.scalarEnd(node, start, slot.flow, false, slot.indent)
+	}
+
+	if node.Style&yaml.FlowStyle != 0 {
+		return d.flowCollectionEnd(start)
+	}
+	// a block collection ends with its last entry and a comment after it.
+	entries := len(node.Content)
+	if node.Kind == yaml.MappingNode {
+		entries /= 2
+	}
+	return len(strings.TrimRight(d.content[:d.lineEnd(d.nodeEnd(d.entrySlot(slot, entries-1)))], " \t\r"))
+}
+
+// scalarEnd returns the offset after the text of the scalar starting at the offset. A plain scalar of
+// a key ends before its `:`, a plain or block scalar of a value continues on the lines which are indented
+// more than its entry.
+func (d *yamlDocument) scalarEnd(node *yaml.Node, start int, flow, key bool, indent int) int {
+	content := d.content
+	switch {
+	case node.Style&yaml.DoubleQuotedStyle != 0:
+		for i := start + 1; i < len(content); i++ {
+			switch content[i] {
+			case '\\':
+				i++
+			case '"':
+				return i + 1
+			}
+		}
+		return len(content)
+	case node.Style&yaml.SingleQuotedStyle != 0:
+		for i := start + 1; i < len(content); i++ {
+			if content[i] == '\'' {
+				if i+1 < len(content) && content[i+1] == '\'' {
+					i++
+					continue
+				}
+				return i + 1
+			}
+		}
+		return len(content)
+	}
+
+	end := d.lineEnd(start)
+	plain := node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) == 0
+	if plain {
+		end = d.plainEnd(start, flow, key)
+		if flow || key {
+			return end
+		}
+	}
+	for lineStart := d.lineEnd(end) + 1; lineStart < len(content); lineStart = d.lineEnd(lineStart) + 1 {
+		line := strings.TrimRight(content[lineStart:d.lineEnd(lineStart)], " \t\r")
+		trimmedLine := strings.TrimLeft(line, " ")
+		if trimmedLine == "" {
+			continue
+		}
+		if len(line)-len(trimmedLine) <= indent || (plain && strings.HasPrefix(trimmedLine, "#")) {
+			break
+		}
+		end = lineStart + len(line)
+		if plain {
+			end = d.plainEnd(lineStart+len(line)-len(trimmedLine), false, false)
+		}
+	}
+	return end
+}
+
+// plainEnd returns the offset after the plain scalar on the line starting at the offset.
+func (d *yamlDocument) plainEnd(start int, flow, key bool) int {
+	content := d.content
+	end := start
+	for ; end < len(content) && content[end] != '\n'; end++ {
+		c := content[end]
+		if c == '#' && end > start && (content[end-1] == ' ' || content[end-1] == '\t') {
+			break
+		}
+		if flow && strings.IndexByte(",[]{}", c) >= 0 {
+			break
+		}
+		if c == ':' && (flow || key) && (end+1 == len(content) || strings.IndexByte(" \t\r\n,[]{}", content[end+1]) >= 0) {
+			break
+		}
+	}
+	return len(strings.TrimRight(content[:end], " \t\r"))
+}
+
+// flowCollectionEnd returns the offset after the flow collection starting at the offset.
+func (d *yamlDocument) flowCollectionEnd(start int) int {
+	content := d.content
+	depth := 0
+	for i := start; i < len(content); i++ {
+		switch c := content[i]; c {
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		case '"', '\'':
+			for i++; i < len(content) && content[i] != c; i++ {
+				if c == '"' && content[i] == '\\' {
+					i++
+				}
+			}
+		case '#':
+			if content[i-1] == ' ' || content[i-1] == '\t' || content[i-1] == '\n' {
+				i = d.lineEnd(i)
+			}
+		}
+	}
+	return len(content)
+}
+
+// skipProperties returns the offset after the anchor and tag of the node starting at the offset.
+func (d *yamlDocument) skipProperties(node *yaml.Node, start int) int {
+	if node.Anchor == "" && node.Style&yaml.TaggedStyle == 0 {
+		return start
+	}
+	for start < len(d.content) && (d.content[start] == '&' || d.content[start] == '!') {
+		for start < len(d.content) && strings.IndexByte(" \t\r\n", d.content[start]) < 0 {
+			start++
+		}
+		for start < len(d.content) && (d.content[start] == ' ' || d.content[start] == '\t') {
+			start++
+		}
+	}
+	return start
+}
+
+func (d *yamlDocument) lineStart(offset int) int {
+	return strings.LastIndexByte(d.content[:offset], '\n') + 1
+}
+
+// lineBreak returns the offset of the line break of the line of the offset, which is the `\r` of a `\r\n`.
+func (d *yamlDocument) lineBreak(offset int) int {
+	end := d.lineEnd(offset)
+	if end > offset && d.content[end-1] == '\r' {
+		return end - 1
+	}
+	return end
+}
+
+func (d *yamlDocument) lineEnd(offset int) int {
+	if offset >= len(d.content) {
+		return len(d.content)
+	}
+	if i := strings.IndexByte(d.content[offset:], '\n'); i >= 0 {
+		return offset + i
+	}
+	return len(d.content)
+}
+
+// column returns the number of characters before the offset on its line.
+func (d *yamlDocument) column(offset int) int {
+	return utf8.RuneCountInString(d.content[d.lineStart(offset):offset])
+}
+
+// replace replaces the content between the offsets and parses the document again.
+func (d *yamlDocument) replace(start, end int, s string) error {
+	document, err := parseYAMLDocument(d.content[:start] + s + d.content[end:])
+	if err != nil {
+		return fmt.Errorf("failed to patch YAML: %v", err)
+	}
+	*d = *document
+	return nil
+}
+
+// render returns the node of the value rendered by the YAML encoder, without a trailing newline.
+func (d *yamlDocument) render(value interface{}) string {
+	node, ok := value.(*yaml.Node)
+	if !ok {
+		node = yamlValueNode(value)
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(d.indent)
+	// encoding a node built from decoded JSON can't fail.
+	_ = encoder.Encode(node)
+	_ = encoder.Close()
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// renderIndented returns the rendered node, whose lines after the first are indented by the given number of spaces
+// and end with the line ending of the document.
+func (d *yamlDocument) renderIndented(node *yaml.Node, indent int) string {
+	lines := strings.Split(d.render(node), "\n")
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = strings.Repeat(" ", indent) + lines[i]
+		}
+	}
+	return strings.Join(lines, d.newline)
+}
+
+// renderFlow returns the node rendered for a flow collection, which is a single line.
+// Collections are rendered as JSON, which is valid YAML.
+func (d *yamlDocument) renderFlow(node *yaml.Node, value interface{}) string {
+	if node.Kind != yaml.ScalarNode {
+		return encodeOrderedJSON(value, "")
+	}
+	if node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+		node.Style = yaml.DoubleQuotedStyle
+	}
+	rendered := d.render(node)
+	if strings.Contains(rendered, "\n") || (node.Style == 0 && strings.ContainsAny(rendered, ",[]{}")) {
+		node.Style = yaml.DoubleQuotedStyle
+		rendered = d.render(node)
+	}
+	return rendered
+}
+
+// yamlMappingIndex returns the index of the entry of the key in the mapping or -1 if it doesn't exist.
+func yamlMappingIndex(node *yaml.Node, key string) int {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return i / 2
+		}
+	}
+	return -1
+}
+
+// yamlNodeValue returns the value of the node as decoded JSON.
+func yamlNodeValue(node *yaml.Node) (interface{}, error) {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		object := orderedObject{}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			value, err := yamlNodeValue(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			object = append(object, orderedField{Key: node.Content[i].Value, Value: value})
+		}
+		return object, nil
+	case yaml.SequenceNode:
+		array := make([]interface{}, 0, len(node.Content))
+		for _, element := range node.Content {
+			value, err := yamlNodeValue(element)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		return array, nil
+	}
+
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return nil, err
+	}
+	switch v := value.(type) {
+	case int:
+		return json.Number(strconv.Itoa(v)), nil
+	case float64:
+		// infinity and NaN can't be represented in JSON.
+		if number, err := json.Marshal(v); err == nil {
+			return json.Number(number), nil
+		}
+		return node.Value, nil
+	case string, bool, nil:
+		return v, nil
+	}
+	// other scalars, e.g. timestamps, are compared by their text.
+	return node.Value, nil
+}
+
+// yamlValueNode returns the node of the given decoded JSON value.
+func yamlValueNode(value interface{}) *yaml.Node {
+	switch v := value.(type) {
+	case orderedObject:
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, field := range v {
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: field.Key}, yamlValueNode(field.Value))
+		}
+		return node
+	case []interface{}:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, element := range v {
+			node.Content = append(node.Content, yamlValueNode(element))
+		}
+		return node
+	case json.Number:
+		tag := "!!int"
+		if strings.ContainsAny(v.String(), ".eE") {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: v.String()}
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(v)}
+	case nil:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value.(string)}
+}